@@ -3,19 +3,75 @@ package web
 import (
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 )
 
+// Context
+// recycled by HTTPServer once the request is served,
+// so it must not be retained after the handler returns
 type Context struct {
 	Req        *http.Request
 	Resp       http.ResponseWriter
-	PathParams map[string]string
+	PathParams Params
+
+	// backing of Resp, kept in Context to save an allocation per request
+	rw responseWriter
 
 	// "Context.Req.URL.Query()" will execute parse action everytime
 	// So cache it here for repeat usage
 	parsedQuery url.Values
+
+	// set by HTTPServer, used by Error and Render
+	errorHandler ErrorHandler
+	tplEngine    TemplateEngine
+	// the logger of HTTPServer, used by Recovery
+	logger *log.Logger
+}
+
+// reset
+// prepare a recycled Context for the next request,
+// reset(nil, nil) drops the references before it is put back to the pool
+func (c *Context) reset(writer http.ResponseWriter, request *http.Request) {
+	c.Req = request
+	c.rw = responseWriter{ResponseWriter: writer}
+	c.Resp = &c.rw
+	c.PathParams = c.PathParams[:0]
+	c.parsedQuery = nil
+}
+
+// Param
+// a path param in the request path, such as "id" of "/order/:id"
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params
+// path params in the order of the request path,
+// a slice instead of a map so that it can be reused across requests
+type Params []Param
+
+func (ps Params) Get(key string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ErrorHandler
+// respond an error reported by Context.Error
+type ErrorHandler func(ctx *Context, err error)
+
+// defaultErrorHandler
+// respond 500 without the detail of err, which may leak internal information
+func defaultErrorHandler(ctx *Context, err error) {
+	ctx.Resp.WriteHeader(http.StatusInternalServerError)
+	ctx.Resp.Write([]byte("INTERNAL SERVER ERROR"))
 }
 
 func (c *Context) BindJSON(val any) error {
@@ -48,13 +104,47 @@ func (c *Context) QueryValue(key string) StringValue {
 }
 
 func (c *Context) PathParamValue(key string) StringValue {
-	v, ok := c.PathParams[key]
+	v, ok := c.PathParams.Get(key)
 	if !ok {
 		return StringValue{str: "", err: errors.New(key + ": key not found")}
 	}
 	return StringValue{str: v}
 }
 
+// Error
+// hand err over to the ErrorHandler of HTTPServer
+func (c *Context) Error(err error) {
+	if c.errorHandler == nil {
+		defaultErrorHandler(c, err)
+		return
+	}
+	c.errorHandler(c, err)
+}
+
+// Render
+// render the template tplName with data by the TemplateEngine of HTTPServer
+func (c *Context) Render(tplName string, data any) error {
+	if c.tplEngine == nil {
+		return errors.New("template engine not set")
+	}
+	page, err := c.tplEngine.Render(c.Req.Context(), tplName, data)
+	if err != nil {
+		return err
+	}
+
+	c.Resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Resp.WriteHeader(http.StatusOK)
+	_, err = c.Resp.Write(page)
+	return err
+}
+
+// Written
+// report whether the response headers were already sent
+func (c *Context) Written() bool {
+	w, ok := c.Resp.(*responseWriter)
+	return ok && w.wroteHeader
+}
+
 func (c *Context) RespJSONOK(v any) error {
 	return c.RespJSON(http.StatusOK, v)
 }
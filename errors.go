@@ -0,0 +1,53 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidRoute
+	// the path of the route is malformed, such as "user" or "/user//home"
+	ErrInvalidRoute = errors.New("web: invalid route")
+	// ErrDuplicateRoute
+	// the route is already registered
+	ErrDuplicateRoute = errors.New("web: duplicate route")
+	// ErrConflictingRoute
+	// the route can not exist with a registered one,
+	// such as "/user/:id" and "/user/*"
+	ErrConflictingRoute = errors.New("web: conflicting route")
+)
+
+// RouteError
+// returned by TryHandle, check the kind by errors.Is
+// for example:
+//
+//	_, err := server.TryHandle(http.MethodGet, "/user/*", handleFunc)
+//	var routeErr *RouteError
+//	if errors.Is(err, ErrConflictingRoute) && errors.As(err, &routeErr) {
+//		fmt.Println(routeErr.Path, routeErr.Existing)
+//	}
+type RouteError struct {
+	// ErrInvalidRoute, ErrDuplicateRoute or ErrConflictingRoute
+	Err    error
+	Method string
+	Path   string
+	// the registered route, for ErrDuplicateRoute and ErrConflictingRoute
+	Existing string
+	Reason   string
+}
+
+func (e *RouteError) Error() string {
+	msg := fmt.Sprintf("%v: %s %s", e.Err, e.Method, e.Path)
+	if e.Existing != "" {
+		msg += ", existing " + e.Existing
+	}
+	if e.Reason != "" {
+		msg += ", " + e.Reason
+	}
+	return msg
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPServer_TryHandle(t *testing.T) {
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	s := NewHTTPServer()
+	for _, path := range []string{"/", "/user/:id", "/files/*filepath", "/order/:id(^[0-9]+$)", "/a/:name.:ext", "/p/:id/x"} {
+		_, err := s.TryHandle(http.MethodGet, path, fakeHandleFunc)
+		require.NoError(t, err, path)
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		expectErr      error
+		expectExisting string
+	}{
+		{name: "empty", path: "", expectErr: ErrInvalidRoute},
+		{name: "no leading slash", path: "user", expectErr: ErrInvalidRoute},
+		{name: "trailing slash", path: "/user/", expectErr: ErrInvalidRoute},
+		{name: "double slash", path: "/user//home", expectErr: ErrInvalidRoute},
+		{name: "catch-all not last", path: "/x/*fp/y", expectErr: ErrInvalidRoute},
+		{name: "invalid regex", path: "/x/:id([0-9]+", expectErr: ErrInvalidRoute},
+		{name: "invalid pattern", path: "/x/:a:b", expectErr: ErrInvalidRoute},
+		{name: "duplicate root", path: "/", expectErr: ErrDuplicateRoute, expectExisting: "/"},
+		{name: "duplicate", path: "/user/:id", expectErr: ErrDuplicateRoute, expectExisting: "/user/:id"},
+		{name: "duplicate renamed param", path: "/user/:uid", expectErr: ErrDuplicateRoute, expectExisting: "/user/:id"},
+		{name: "duplicate optional", path: "/user/:id?", expectErr: ErrDuplicateRoute, expectExisting: "/user/:id"},
+		{name: "wildcard after param", path: "/user/*", expectErr: ErrConflictingRoute, expectExisting: "/user/:id"},
+		{name: "regex after param", path: "/user/:id(.*)", expectErr: ErrConflictingRoute, expectExisting: "/user/:id"},
+		{name: "param after catch-all", path: "/files/:name", expectErr: ErrConflictingRoute, expectExisting: "/files/*filepath"},
+		{name: "another regex", path: "/order/:id(^[a-z]+$)", expectErr: ErrConflictingRoute, expectExisting: "/order/:id(^[0-9]+$)"},
+		{name: "pattern of same shape", path: "/a/:file.:type", expectErr: ErrConflictingRoute, expectExisting: "/a/:name.:ext"},
+		{name: "renamed param of another route", path: "/p/:identifier/y", expectErr: ErrConflictingRoute, expectExisting: "/p/:id/x"},
+		{name: "renamed param inside the path", path: "/q/:x?/:y", expectErr: ErrConflictingRoute, expectExisting: "/q/:x?/:y"},
+		{name: "optional expands twice", path: "/b/:x?/:y?", expectErr: ErrDuplicateRoute, expectExisting: "/b/:y"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			route, err := s.TryHandle(method, tc.path, fakeHandleFunc)
+			assert.Nil(t, route)
+			assert.ErrorIs(t, err, tc.expectErr)
+
+			var routeErr *RouteError
+			require.True(t, errors.As(err, &routeErr))
+			assert.Equal(t, method, routeErr.Method)
+			assert.Equal(t, tc.path, routeErr.Path)
+			assert.Equal(t, tc.expectExisting, routeErr.Existing)
+		})
+	}
+
+	// TEST: failed registration leaves the tree untouched
+	assert.Equal(t, []RouteInfo{
+		{Method: http.MethodGet, Path: "/", Handler: funcName(fakeHandleFunc), Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/a/:name.:ext", Handler: funcName(fakeHandleFunc), Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/files/*filepath", Handler: funcName(fakeHandleFunc), Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/order/:id(^[0-9]+$)", Handler: funcName(fakeHandleFunc), Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/p/:id/x", Handler: funcName(fakeHandleFunc), Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/user/:id", Handler: funcName(fakeHandleFunc), Middlewares: []string{}},
+	}, s.Routes())
+	_, found := s.findRoute(http.MethodGet, "/b", nil)
+	assert.False(t, found)
+
+	// TEST: other methods are not affected
+	_, err := s.TryHandle(http.MethodPost, "/user/*", fakeHandleFunc)
+	assert.NoError(t, err)
+
+	// TEST: Handle panics with the same error
+	assert.PanicsWithError(t, (&RouteError{Err: ErrDuplicateRoute, Method: http.MethodGet, Path: "/", Existing: "/"}).Error(), func() {
+		s.Get("/", fakeHandleFunc)
+	})
+}
+
+func TestGroup_TryHandle(t *testing.T) {
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	g := NewHTTPServer().Group("/api")
+
+	_, err := g.TryHandle(http.MethodGet, "user", fakeHandleFunc)
+	assert.ErrorIs(t, err, ErrInvalidRoute)
+
+	route, err := g.TryHandle(http.MethodGet, "/user", fakeHandleFunc)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/user", route.Path)
+
+	_, err = g.TryHandle(http.MethodGet, "/user", fakeHandleFunc)
+	var routeErr *RouteError
+	require.True(t, errors.As(err, &routeErr))
+	assert.ErrorIs(t, err, ErrDuplicateRoute)
+	assert.Equal(t, "/api/user", routeErr.Existing)
+}
@@ -0,0 +1,130 @@
+package web
+
+import "net/http"
+
+// Group
+// register routes under a shared prefix with shared middlewares
+// for example:
+//
+//	v1 := server.Group("/api/v1", authMiddleware)
+//	v1.Get("/user", handleFunc) // GET /api/v1/user
+type Group struct {
+	// "/" is stored as "", so that joined path never contains "//"
+	prefix string
+	// inherited from parent group, then appended by this group
+	mws []Middleware
+	// the server's router, or a host router, see HTTPServer.Host
+	router *router
+	// inherited from parent group, see HTTPServer.Match
+	matchers []Matcher
+}
+
+func (h *HTTPServer) Group(prefix string, mws ...Middleware) *Group {
+	g := &Group{router: h.router}
+	return g.Group(prefix, mws...)
+}
+
+// Group
+// create a nested group, prefix and middlewares are appended to the parent's
+func (g *Group) Group(prefix string, mws ...Middleware) *Group {
+	if prefix == "" || prefix[:1] != "/" {
+		panic("Group Check Error: [prefix] must be start with '/'!")
+	}
+
+	if len(prefix) > 1 && prefix[len(prefix)-1:] == "/" {
+		panic("Group Check Error: [prefix] last character can not be '/'!")
+	}
+
+	if prefix == "/" {
+		prefix = ""
+	}
+
+	return &Group{
+		prefix:   g.prefix + prefix,
+		mws:      g.middlewares(mws),
+		router:   g.router,
+		matchers: g.matchers,
+	}
+}
+
+func (g *Group) Handle(method string, path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	rt, err := g.router.tryAddMatchedRoute(method, g.fullPath(path), g.matchers, handleFunc, g.middlewares(mws)...)
+	if err != nil {
+		panic(err)
+	}
+	return rt
+}
+
+// TryHandle
+// like Handle, but return *RouteError instead of panicking
+func (g *Group) TryHandle(method string, path string, handleFunc HandleFunc, mws ...Middleware) (*Route, error) {
+	if path == "" || path[:1] != "/" {
+		return nil, &RouteError{Err: ErrInvalidRoute, Method: method, Path: path, Reason: "[path] must be start with '/'"}
+	}
+	return g.router.tryAddMatchedRoute(method, g.fullPath(path), g.matchers, handleFunc, g.middlewares(mws)...)
+}
+
+func (g *Group) Get(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodGet, path, handleFunc, mws...)
+}
+
+func (g *Group) Post(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodPost, path, handleFunc, mws...)
+}
+
+func (g *Group) Put(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodPut, path, handleFunc, mws...)
+}
+
+func (g *Group) Patch(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodPatch, path, handleFunc, mws...)
+}
+
+func (g *Group) Delete(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodDelete, path, handleFunc, mws...)
+}
+
+func (g *Group) Head(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodHead, path, handleFunc, mws...)
+}
+
+func (g *Group) Options(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodOptions, path, handleFunc, mws...)
+}
+
+func (g *Group) Connect(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodConnect, path, handleFunc, mws...)
+}
+
+func (g *Group) Trace(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return g.Handle(http.MethodTrace, path, handleFunc, mws...)
+}
+
+// Any
+// register handleFunc on all http methods
+func (g *Group) Any(path string, handleFunc HandleFunc, mws ...Middleware) {
+	for _, method := range methods {
+		g.Handle(method, path, handleFunc, mws...)
+	}
+}
+
+// fullPath
+// join group prefix and route path, "/" in a group means the prefix itself
+func (g *Group) fullPath(path string) string {
+	if path == "" || path[:1] != "/" {
+		panic("Route Check Error: [path] must be start with '/'!")
+	}
+
+	if path == "/" && g.prefix != "" {
+		return g.prefix
+	}
+	return g.prefix + path
+}
+
+// middlewares
+// return a new slice, so that sibling groups never share the backing array
+func (g *Group) middlewares(mws []Middleware) []Middleware {
+	res := make([]Middleware, 0, len(g.mws)+len(mws))
+	res = append(res, g.mws...)
+	return append(res, mws...)
+}
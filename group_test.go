@@ -0,0 +1,97 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	var trace []string
+	mark := func(name string) Middleware {
+		return func(next HandleFunc) HandleFunc {
+			return func(ctx *Context) {
+				trace = append(trace, name)
+				next(ctx)
+			}
+		}
+	}
+	handler := func(name string) HandleFunc {
+		return func(ctx *Context) {
+			trace = append(trace, name)
+		}
+	}
+
+	s := NewHTTPServer()
+	api := s.Group("/api", mark("api"))
+	v1 := api.Group("/v1", mark("v1"))
+	v2 := api.Group("/v2")
+	api.Get("/", handler("api index"))
+	v1.Get("/user", handler("v1 user"), mark("route"))
+	v1.Post("/user/:id", handler("v1 update user"))
+	v2.Get("/user", handler("v2 user"))
+	s.Group("/").Get("/", handler("root"))
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		expect []string
+	}{
+		{
+			name:   "group index",
+			method: http.MethodGet,
+			path:   "/api",
+			expect: []string{"api", "api index"},
+		},
+		{
+			name:   "nested group",
+			method: http.MethodGet,
+			path:   "/api/v1/user",
+			expect: []string{"api", "v1", "route", "v1 user"},
+		},
+		{
+			name:   "nested group with param",
+			method: http.MethodPost,
+			path:   "/api/v1/user/1",
+			expect: []string{"api", "v1", "v1 update user"},
+		},
+		{
+			name:   "sibling group does not inherit",
+			method: http.MethodGet,
+			path:   "/api/v2/user",
+			expect: []string{"api", "v2 user"},
+		},
+		{
+			name:   "root group",
+			method: http.MethodGet,
+			path:   "/",
+			expect: []string{"root"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trace = nil
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			s.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.expect, trace)
+		})
+	}
+
+	// TEST: CHECK [prefix]
+	assert.Panics(t, func() {
+		s.Group("")
+	})
+	assert.Panics(t, func() {
+		s.Group("api")
+	})
+	assert.Panics(t, func() {
+		s.Group("/api/")
+	})
+	assert.Panics(t, func() {
+		api.Get("user", handler("user"))
+	})
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Hook
+// run by HTTPServer on start or shutdown, in the order of registration
+// ctx of a shutdown hook carries the deadline of Shutdown
+type Hook func(ctx context.Context) error
+
+// OnStart
+// register hooks run after the listener is bound, before serving requests
+// Start returns the first error of them without serving
+func (h *HTTPServer) OnStart(hooks ...Hook) {
+	h.startHooks = append(h.startHooks, hooks...)
+}
+
+// OnShutdown
+// register hooks run by Shutdown after in-flight requests are drained,
+// such as flushing caches or deregistering from service discovery
+func (h *HTTPServer) OnShutdown(hooks ...Hook) {
+	h.shutdownHooks = append(h.shutdownHooks, hooks...)
+}
+
+// Shutdown
+// 1. stop accepting new connections
+// 2. wait for in-flight requests until ctx is done
+// 3. run shutdown hooks, all of them run even if some fail
+// the first error is returned
+func (h *HTTPServer) Shutdown(ctx context.Context) error {
+	err := h.server.Shutdown(ctx)
+	if err != nil {
+		h.logger.Printf("web: shutdown server: %v", err)
+	}
+
+	for _, hook := range h.shutdownHooks {
+		hookErr := hook(ctx)
+		if hookErr == nil {
+			continue
+		}
+		h.logger.Printf("web: shutdown hook: %v", hookErr)
+		if err == nil {
+			err = hookErr
+		}
+	}
+	return err
+}
+
+// ShutdownOnSignal
+// block until SIGINT or SIGTERM is received, then Shutdown within timeout
+// for example:
+//
+//	go func() {
+//		if err := server.Start(":8080"); err != nil {
+//			log.Fatal(err)
+//		}
+//	}()
+//	err := server.ShutdownOnSignal(10 * time.Second)
+func (h *HTTPServer) ShutdownOnSignal(timeout time.Duration) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	<-signals
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return h.Shutdown(ctx)
+}
+
+func (h *HTTPServer) runStartHooks(ctx context.Context) error {
+	for _, hook := range h.startHooks {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
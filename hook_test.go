@@ -0,0 +1,53 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPServer_Shutdown(t *testing.T) {
+	var trace []string
+	hook := func(name string, err error) Hook {
+		return func(ctx context.Context) error {
+			trace = append(trace, name)
+			return err
+		}
+	}
+
+	started := make(chan struct{})
+	s := NewHTTPServer()
+	s.OnStart(hook("start1", nil), func(ctx context.Context) error {
+		close(started)
+		return nil
+	})
+	s.OnShutdown(
+		hook("shutdown1", nil),
+		hook("shutdown2", errors.New("deregister failed")),
+		hook("shutdown3", nil),
+	)
+
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- s.Start("127.0.0.1:0")
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	assert.EqualError(t, err, "deregister failed")
+	assert.NoError(t, <-startErr)
+	assert.Equal(t, []string{"start1", "shutdown1", "shutdown2", "shutdown3"}, trace)
+}
+
+func TestHTTPServer_StartHookError(t *testing.T) {
+	s := NewHTTPServer()
+	s.OnStart(func(ctx context.Context) error {
+		return errors.New("register failed")
+	})
+	assert.EqualError(t, s.Start("127.0.0.1:0"), "register failed")
+}
@@ -0,0 +1,136 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hostRouter
+// the routes of a host pattern, see HTTPServer.Host
+type hostRouter struct {
+	*router
+
+	pattern string
+	// labels of pattern, ":name" captures a label of the request host
+	labels []string
+}
+
+// Host
+// register routes served only for the requests to the host matching pattern
+// pattern is an exact host such as "api.example.com",
+// or has ":name" labels such as ":tenant.example.com", captured into Context.PathParams
+// exact hosts are tried first, then the patterns in registration order,
+// requests to other hosts are routed by the routes registered on the server directly,
+// the port of the request host is ignored and hosts are matched case-insensitively
+// for example:
+//
+//	tenant := server.Host(":tenant.example.com")
+//	tenant.Get("/home", handleFunc) // ctx.PathParams.Get("tenant")
+func (h *HTTPServer) Host(pattern string, mws ...Middleware) *Group {
+	pattern = strings.ToLower(pattern)
+	labels := strings.Split(pattern, ".")
+	exact := true
+	for _, label := range labels {
+		if label == "" || label == ":" {
+			panic(fmt.Sprintf("Host Check Error: [%s] label can not be empty!", pattern))
+		}
+		if strings.IndexByte(label, ':') > 0 {
+			panic(fmt.Sprintf("Host Check Error: [%s] port and partial label param are not supported!", pattern))
+		}
+		if label[0] == ':' {
+			exact = false
+		}
+	}
+
+	hr := h.lookupHost(pattern, exact)
+	if hr == nil {
+		r := newRadixRouter()
+		r.caseInsensitive = h.caseInsensitive
+		// route names are unique across hosts, so URLFor finds them all
+		r.named = h.named
+		hr = &hostRouter{router: r, pattern: pattern, labels: labels}
+
+		if exact {
+			if h.exactHosts == nil {
+				h.exactHosts = map[string]*hostRouter{}
+			}
+			h.exactHosts[pattern] = hr
+		} else {
+			h.patternHosts = append(h.patternHosts, hr)
+		}
+	}
+
+	return &Group{mws: mws, router: hr.router}
+}
+
+// lookupHost
+// the router registered for pattern by Host, nil if there is none
+func (h *HTTPServer) lookupHost(pattern string, exact bool) *hostRouter {
+	if exact {
+		return h.exactHosts[pattern]
+	}
+	for _, hr := range h.patternHosts {
+		if hr.pattern == pattern {
+			return hr
+		}
+	}
+	return nil
+}
+
+// routerFor
+// select the router for host, the Host of the request,
+// params is appended with the labels captured by the host pattern
+func (h *HTTPServer) routerFor(host string, params Params) (*router, Params) {
+	if len(h.exactHosts) == 0 && len(h.patternHosts) == 0 {
+		return h.router, params
+	}
+
+	// no allocation for a lower case host
+	host = strings.ToLower(stripPort(host))
+
+	if hr, ok := h.exactHosts[host]; ok {
+		return hr.router, params
+	}
+	for _, hr := range h.patternHosts {
+		if ps, ok := hr.match(host, params); ok {
+			return hr.router, ps
+		}
+	}
+	return h.router, params
+}
+
+// match
+// match host label by label, a param label never matches an empty label
+func (hr *hostRouter) match(host string, params Params) (Params, bool) {
+	ps := params
+	for i, label := range hr.labels {
+		value, rest, last := host, "", true
+		if j := strings.IndexByte(host, '.'); j >= 0 {
+			value, rest, last = host[:j], host[j+1:], false
+		}
+		// host has more or less labels than the pattern
+		if last != (i == len(hr.labels)-1) {
+			return params, false
+		}
+
+		if label[0] == ':' {
+			if value == "" {
+				return params, false
+			}
+			ps = append(ps, Param{Key: label[1:], Value: value})
+		} else if value != label {
+			return params, false
+		}
+		host = rest
+	}
+	return ps, true
+}
+
+// stripPort
+// remove the port of host, such as "example.com:8080" or "[::1]:8080"
+func stripPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && i > strings.LastIndexByte(host, ']') {
+		return host[:i]
+	}
+	return host
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPServer_Host(t *testing.T) {
+	write := func(body string) HandleFunc {
+		return func(ctx *Context) {
+			tenant, _ := ctx.PathParams.Get("tenant")
+			id, _ := ctx.PathParams.Get("id")
+			_, _ = ctx.Resp.Write([]byte(body + tenant + id))
+		}
+	}
+	header := func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.Resp.Header().Set("X-Host", "admin")
+			next(ctx)
+		}
+	}
+
+	s := NewHTTPServer()
+	s.Get("/users", write("default "))
+	s.Host("api.example.com").Get("/users", write("api "))
+	s.Host("api.example.com").Get("/users/:id", write("api user "))
+	s.Host("Admin.Example.com", header).Group("/v1").Post("/users", write("admin "))
+	s.Host(":tenant.example.com").Get("/home", write("tenant "))
+	s.Host(":tenant.eu.example.com").Get("/home", write("eu tenant "))
+
+	testCases := []struct {
+		name         string
+		method       string
+		host         string
+		path         string
+		expectCode   int
+		expectBody   string
+		expectHeader http.Header
+	}{
+		{name: "exact host", host: "api.example.com", path: "/users", expectCode: http.StatusOK, expectBody: "api "},
+		{name: "exact host with param", host: "api.example.com", path: "/users/7", expectCode: http.StatusOK, expectBody: "api user 7"},
+		{name: "port is ignored", host: "api.example.com:8080", path: "/users", expectCode: http.StatusOK, expectBody: "api "},
+		{name: "case-insensitive host", host: "API.Example.COM", path: "/users", expectCode: http.StatusOK, expectBody: "api "},
+		{
+			name: "host with group and middleware", method: http.MethodPost, host: "admin.example.com", path: "/v1/users",
+			expectCode: http.StatusOK, expectBody: "admin ", expectHeader: http.Header{"X-Host": {"admin"}},
+		},
+		{name: "exact host before pattern", host: "api.example.com", path: "/home", expectCode: http.StatusNotFound, expectBody: "NOT FOUND"},
+		{name: "pattern host", host: "acme.example.com", path: "/home", expectCode: http.StatusOK, expectBody: "tenant acme"},
+		{name: "pattern host of more labels", host: "acme.eu.example.com", path: "/home", expectCode: http.StatusOK, expectBody: "eu tenant acme"},
+		{name: "other host", host: "example.com", path: "/users", expectCode: http.StatusOK, expectBody: "default "},
+		{name: "other host of the same domain", host: "a.b.c.example.com", path: "/users", expectCode: http.StatusOK, expectBody: "default "},
+		{name: "host routes only", host: "acme.example.com", path: "/users", expectCode: http.StatusNotFound, expectBody: "NOT FOUND"},
+		{
+			name: "method not allowed of host", host: "admin.example.com", path: "/v1/users",
+			expectCode: http.StatusMethodNotAllowed, expectBody: "METHOD NOT ALLOWED",
+			expectHeader: http.Header{"Allow": {"OPTIONS, POST"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.path, nil)
+			req.Host = tc.host
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+			for key, values := range tc.expectHeader {
+				assert.Equal(t, values, recorder.Header().Values(key))
+			}
+		})
+	}
+
+	// TEST: the same pattern shares a router
+	assert.Len(t, s.exactHosts, 2)
+	assert.Len(t, s.patternHosts, 2)
+
+	// TEST: invalid patterns
+	for _, pattern := range []string{"", "example..com", "api.example.com:8080", "v:tenant.example.com", ":.example.com"} {
+		assert.Panics(t, func() {
+			s.Host(pattern)
+		}, pattern)
+	}
+}
+
+func TestHTTPServer_HostRoutes(t *testing.T) {
+	s := NewHTTPServer()
+	s.Get("/users", routesTestHandler)
+	s.Host("api.example.com").Get("/users", routesTestHandler)
+	s.Host(":tenant.example.com").Get("/home", routesTestHandler).Name("tenant-home")
+
+	handler := "Ch01.routesTestHandler"
+	assert.Equal(t, []RouteInfo{
+		{Method: http.MethodGet, Path: "/users", Handler: handler, Middlewares: []string{}},
+		{Host: ":tenant.example.com", Method: http.MethodGet, Path: "/home", Handler: handler, Middlewares: []string{}},
+		{Host: "api.example.com", Method: http.MethodGet, Path: "/users", Handler: handler, Middlewares: []string{}},
+	}, s.Routes())
+
+	// TEST: route names are shared across hosts
+	url, err := s.URLFor("tenant-home", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "/home", url)
+	assert.Panics(t, func() {
+		s.Get("/home", routesTestHandler).Name("tenant-home")
+	})
+}
+
+func TestStripPort(t *testing.T) {
+	testCases := map[string]string{
+		"example.com":      "example.com",
+		"example.com:8080": "example.com",
+		"[::1]:8080":       "[::1]",
+		"[::1]":            "[::1]",
+	}
+	for host, expect := range testCases {
+		assert.Equal(t, expect, stripPort(host), host)
+	}
+}
@@ -0,0 +1,93 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPServer_Serve(t *testing.T) {
+	testCases := []struct {
+		name  string
+		start func(s *HTTPServer) error
+		// client dialing the served address
+		client func(addr net.Addr) *http.Client
+	}{
+		{
+			name: "start on port 0",
+			start: func(s *HTTPServer) error {
+				return s.Start("127.0.0.1:0")
+			},
+			client: func(addr net.Addr) *http.Client {
+				return http.DefaultClient
+			},
+		},
+		{
+			name: "serve on caller provided listener",
+			start: func(s *HTTPServer) error {
+				l, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					return err
+				}
+				return s.Serve(l)
+			},
+			client: func(addr net.Addr) *http.Client {
+				return http.DefaultClient
+			},
+		},
+		{
+			name: "start on unix socket",
+			start: func(s *HTTPServer) error {
+				return s.Start("unix:" + filepath.Join(t.TempDir(), "web.sock"))
+			},
+			client: func(addr net.Addr) *http.Client {
+				return &http.Client{
+					Transport: &http.Transport{
+						DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+							return (&net.Dialer{}).DialContext(ctx, "unix", addr.String())
+						},
+					},
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewHTTPServer()
+			assert.Nil(t, s.Addr())
+			s.Get("/user", func(ctx *Context) {
+				_, _ = ctx.Resp.Write([]byte("hello, /user"))
+			})
+
+			startErr := make(chan error, 1)
+			go func() {
+				startErr <- tc.start(s)
+			}()
+			require.Eventually(t, func() bool {
+				return s.Addr() != nil
+			}, time.Second, time.Millisecond)
+
+			host := s.Addr().String()
+			if s.Addr().Network() == "unix" {
+				host = "unix"
+			}
+			resp, err := tc.client(s.Addr()).Get("http://" + host + "/user")
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, "hello, /user", string(body))
+
+			assert.NoError(t, s.Shutdown(context.Background()))
+			assert.NoError(t, <-startErr)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package web
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Matcher
+// a constraint on the request, a route with matchers is only chosen
+// when all of them match the request, see HTTPServer.Match
+type Matcher interface {
+	Match(req *http.Request) bool
+	// describe the constraint, such as `header Accept="application/json"`
+	// routes of the same path with the same matchers are duplicate
+	String() string
+}
+
+type matcherFunc struct {
+	desc string
+	fn   func(req *http.Request) bool
+}
+
+func (m matcherFunc) Match(req *http.Request) bool {
+	return m.fn(req)
+}
+
+func (m matcherFunc) String() string {
+	return m.desc
+}
+
+// MatchFunc
+// a custom Matcher, desc tells it apart from the others
+func MatchFunc(desc string, fn func(req *http.Request) bool) Matcher {
+	return matcherFunc{desc: desc, fn: fn}
+}
+
+// MatchHeader
+// one of the values of header key equals value,
+// such as MatchHeader("Accept", "application/vnd.v2+json")
+func MatchHeader(key string, value string) Matcher {
+	key = http.CanonicalHeaderKey(key)
+	return MatchFunc(fmt.Sprintf("header %s=%q", key, value), func(req *http.Request) bool {
+		for _, v := range req.Header[key] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchHeaderRegex
+// one of the values of header key matches expr,
+// expr is compiled once, it panics if expr is invalid
+func MatchHeaderRegex(key string, expr string) Matcher {
+	key = http.CanonicalHeaderKey(key)
+	re := regexp.MustCompile(expr)
+	return MatchFunc(fmt.Sprintf("header %s~%q", key, expr), func(req *http.Request) bool {
+		for _, v := range req.Header[key] {
+			if re.MatchString(v) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchQuery
+// the query of the request has key, such as "format" of "?format=csv"
+func MatchQuery(key string) Matcher {
+	return MatchFunc(fmt.Sprintf("query %s", key), func(req *http.Request) bool {
+		return req.URL.Query().Has(key)
+	})
+}
+
+// MatchQueryValue
+// one of the values of query key equals value
+func MatchQueryValue(key string, value string) Matcher {
+	return MatchFunc(fmt.Sprintf("query %s=%q", key, value), func(req *http.Request) bool {
+		for _, v := range req.URL.Query()[key] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchContentType
+// the media type of the request body is one of types,
+// parameters such as "charset=utf-8" are ignored
+func MatchContentType(types ...string) Matcher {
+	return MatchFunc(fmt.Sprintf("content-type %s", strings.Join(types, "|")), func(req *http.Request) bool {
+		mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+		for _, t := range types {
+			if strings.EqualFold(mediaType, t) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Match
+// register routes which are only chosen when all of matchers match the request,
+// the routes of a path are tried in registration order,
+// then the one registered without matchers
+// for example:
+//
+//	server.Match(MatchHeader("Accept", "application/vnd.v2+json")).Get("/users", v2Handler)
+//	server.Match(MatchQueryValue("format", "csv")).Get("/users", csvHandler)
+//	server.Get("/users", handler)
+func (h *HTTPServer) Match(matchers ...Matcher) *Group {
+	g := &Group{router: h.router}
+	return g.Match(matchers...)
+}
+
+// Match
+// create a group with the same prefix and middlewares, matchers are appended to the group's
+func (g *Group) Match(matchers ...Matcher) *Group {
+	return &Group{
+		prefix:   g.prefix,
+		mws:      g.middlewares(nil),
+		router:   g.router,
+		matchers: append(append([]Matcher{}, g.matchers...), matchers...),
+	}
+}
+
+// matchedRoute
+// a route with matchers on a node, see node.handlerFor
+type matchedRoute struct {
+	matchers []Matcher
+	handler  HandleFunc
+	// the full registered path, like node.route
+	route string
+	rt    *Route
+}
+
+// matchAll
+// report whether all of matchers match req
+func matchAll(matchers []Matcher, req *http.Request) bool {
+	for _, m := range matchers {
+		if !m.Match(req) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchersKey
+// identify matchers by their descriptions, "" for no matcher
+func matchersKey(matchers []Matcher) string {
+	descs := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		descs = append(descs, m.String())
+	}
+	return strings.Join(descs, "\n")
+}
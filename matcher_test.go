@@ -0,0 +1,209 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	newReq := func(target string, header http.Header) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header = header
+		return req
+	}
+
+	testCases := []struct {
+		name       string
+		matcher    Matcher
+		req        *http.Request
+		expect     bool
+		expectDesc string
+	}{
+		{
+			name:       "header",
+			matcher:    MatchHeader("accept", "application/vnd.v2+json"),
+			req:        newReq("/", http.Header{"Accept": {"text/html", "application/vnd.v2+json"}}),
+			expect:     true,
+			expectDesc: `header Accept="application/vnd.v2+json"`,
+		},
+		{
+			name:    "header not equal",
+			matcher: MatchHeader("Accept", "application/vnd.v2+json"),
+			req:     newReq("/", http.Header{"Accept": {"application/json"}}),
+			expect:  false,
+		},
+		{
+			name:       "header regex",
+			matcher:    MatchHeaderRegex("Accept", `^application/vnd\.v[2-9]\+json$`),
+			req:        newReq("/", http.Header{"Accept": {"application/vnd.v3+json"}}),
+			expect:     true,
+			expectDesc: `header Accept~"^application/vnd\\.v[2-9]\\+json$"`,
+		},
+		{
+			name:    "header regex without header",
+			matcher: MatchHeaderRegex("Accept", `v2`),
+			req:     newReq("/", http.Header{}),
+			expect:  false,
+		},
+		{
+			name:       "query",
+			matcher:    MatchQuery("format"),
+			req:        newReq("/?format=", http.Header{}),
+			expect:     true,
+			expectDesc: "query format",
+		},
+		{
+			name:    "query missing",
+			matcher: MatchQuery("format"),
+			req:     newReq("/?page=1", http.Header{}),
+			expect:  false,
+		},
+		{
+			name:       "query value",
+			matcher:    MatchQueryValue("format", "csv"),
+			req:        newReq("/?format=json&format=csv", http.Header{}),
+			expect:     true,
+			expectDesc: `query format="csv"`,
+		},
+		{
+			name:       "content type with params",
+			matcher:    MatchContentType("application/json", "application/x-www-form-urlencoded"),
+			req:        newReq("/", http.Header{"Content-Type": {"Application/JSON; charset=utf-8"}}),
+			expect:     true,
+			expectDesc: "content-type application/json|application/x-www-form-urlencoded",
+		},
+		{
+			name:    "content type missing",
+			matcher: MatchContentType("application/json"),
+			req:     newReq("/", http.Header{}),
+			expect:  false,
+		},
+		{
+			name:       "func",
+			matcher:    MatchFunc("tls", func(req *http.Request) bool { return req.TLS != nil }),
+			req:        newReq("/", http.Header{}),
+			expect:     false,
+			expectDesc: "tls",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, tc.matcher.Match(tc.req))
+			if tc.expectDesc != "" {
+				assert.Equal(t, tc.expectDesc, tc.matcher.String())
+			}
+		})
+	}
+
+	assert.Panics(t, func() {
+		MatchHeaderRegex("Accept", "v(2")
+	})
+}
+
+func TestHTTPServer_Match(t *testing.T) {
+	write := func(body string) HandleFunc {
+		return func(ctx *Context) {
+			_, _ = ctx.Resp.Write([]byte(body))
+		}
+	}
+
+	s := NewHTTPServer()
+	v2 := MatchHeader("Accept", "application/vnd.v2+json")
+	s.Match(v2).Get("/users", write("v2"))
+	s.Match(MatchQueryValue("format", "csv")).Get("/users", write("csv"))
+	s.Match(MatchQuery("format")).Get("/users", write("format"))
+	s.Get("/users", write("default"))
+
+	api := s.Group("/api").Match(MatchContentType("application/json"))
+	api.Post("/users", write("json"))
+	api.Match(v2).Post("/users", write("json v2"))
+
+	// only constrained routes on the static node, backtracked to the param one
+	s.Match(v2).Get("/files/latest", write("latest v2"))
+	s.Get("/files/:name", write("file"))
+
+	testCases := []struct {
+		name       string
+		method     string
+		target     string
+		header     http.Header
+		expectCode int
+		expectBody string
+	}{
+		{name: "header", target: "/users", header: http.Header{"Accept": {"application/vnd.v2+json"}}, expectCode: http.StatusOK, expectBody: "v2"},
+		{name: "first matched route wins", target: "/users?format=csv", header: http.Header{"Accept": {"application/vnd.v2+json"}}, expectCode: http.StatusOK, expectBody: "v2"},
+		{name: "query value", target: "/users?format=csv", expectCode: http.StatusOK, expectBody: "csv"},
+		{name: "query", target: "/users?format=xml", expectCode: http.StatusOK, expectBody: "format"},
+		{name: "fallback", target: "/users", expectCode: http.StatusOK, expectBody: "default"},
+		{
+			name: "group matchers", method: http.MethodPost, target: "/api/users",
+			header:     http.Header{"Content-Type": {"application/json"}},
+			expectCode: http.StatusOK, expectBody: "json",
+		},
+		{
+			name: "nested group matchers", method: http.MethodPost, target: "/api/users",
+			header:     http.Header{"Content-Type": {"application/json"}, "Accept": {"application/vnd.v2+json"}},
+			expectCode: http.StatusOK, expectBody: "json",
+		},
+		{
+			name: "no matched route", method: http.MethodPost, target: "/api/users",
+			header:     http.Header{"Content-Type": {"text/plain"}},
+			expectCode: http.StatusNotFound, expectBody: "NOT FOUND",
+		},
+		{
+			name: "other method", method: http.MethodPut, target: "/api/users",
+			expectCode: http.StatusMethodNotAllowed, expectBody: "METHOD NOT ALLOWED",
+		},
+		{name: "static matched", target: "/files/latest", header: http.Header{"Accept": {"application/vnd.v2+json"}}, expectCode: http.StatusOK, expectBody: "latest v2"},
+		{name: "backtrack to param", target: "/files/latest", expectCode: http.StatusOK, expectBody: "file"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.target, nil)
+			for key, values := range tc.header {
+				req.Header[key] = values
+			}
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestHTTPServer_MatchRoutes(t *testing.T) {
+	s := NewHTTPServer()
+	v2 := MatchHeader("Accept", "application/vnd.v2+json")
+	s.Match(v2).Get("/users", routesTestHandler)
+	s.Match(MatchQuery("format")).Get("/users", routesTestHandler)
+	s.Get("/users", routesTestHandler)
+
+	// TEST: duplicate only with the same matchers
+	_, err := s.Match(MatchHeader("accept", "application/vnd.v2+json")).TryHandle(http.MethodGet, "/users", routesTestHandler)
+	assert.ErrorIs(t, err, ErrDuplicateRoute)
+	_, err = s.Match(v2, MatchQuery("format")).TryHandle(http.MethodGet, "/users", routesTestHandler)
+	assert.NoError(t, err)
+
+	handler := "Ch01.routesTestHandler"
+	assert.Equal(t, []RouteInfo{
+		{Method: http.MethodGet, Path: "/users", Handler: handler, Middlewares: []string{}, Matchers: []string{`header Accept="application/vnd.v2+json"`}},
+		{Method: http.MethodGet, Path: "/users", Handler: handler, Middlewares: []string{}, Matchers: []string{"query format"}},
+		{Method: http.MethodGet, Path: "/users", Handler: handler, Middlewares: []string{}, Matchers: []string{`header Accept="application/vnd.v2+json"`, "query format"}},
+		{Method: http.MethodGet, Path: "/users", Handler: handler, Middlewares: []string{}},
+	}, s.Routes())
+
+	// TEST: table
+	buffer := &strings.Builder{}
+	s.PrintRoutes(buffer)
+	assert.Contains(t, buffer.String(), `/users [header Accept="application/vnd.v2+json", query format]`)
+}
@@ -0,0 +1,24 @@
+package web
+
+// Middleware
+// wrap a HandleFunc with extra logic, such as logging, auth or recovery
+// for example:
+//
+//	func(next HandleFunc) HandleFunc {
+//		return func(ctx *Context) {
+//			// before
+//			next(ctx)
+//			// after
+//		}
+//	}
+type Middleware func(next HandleFunc) HandleFunc
+
+// chain
+// compose mws around h, the first middleware is the outermost one,
+// so for mws [m1, m2] the execution order is m1 -> m2 -> h -> m2 -> m1
+func chain(h HandleFunc, mws []Middleware) HandleFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
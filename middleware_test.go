@@ -0,0 +1,61 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPServer_Use(t *testing.T) {
+	var trace []string
+	mark := func(name string) Middleware {
+		return func(next HandleFunc) HandleFunc {
+			return func(ctx *Context) {
+				trace = append(trace, name+" before")
+				next(ctx)
+				trace = append(trace, name+" after")
+			}
+		}
+	}
+
+	s := NewHTTPServer()
+	s.Use(mark("global1"), mark("global2"))
+	s.Get("/user", func(ctx *Context) {
+		trace = append(trace, "handler")
+	}, mark("route"))
+
+	testCases := []struct {
+		name   string
+		path   string
+		expect []string
+	}{
+		{
+			name: "global and route middlewares",
+			path: "/user",
+			expect: []string{
+				"global1 before", "global2 before", "route before",
+				"handler",
+				"route after", "global2 after", "global1 after",
+			},
+		},
+		{
+			name: "global middlewares on not found",
+			path: "/not/exist",
+			expect: []string{
+				"global1 before", "global2 before",
+				"global2 after", "global1 after",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trace = nil
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			s.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.expect, trace)
+		})
+	}
+}
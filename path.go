@@ -0,0 +1,150 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// PathPolicy
+// how HTTPServer handles the request paths which are not canonical,
+// such as "/user/", "//user" or "/user/../user"
+type PathPolicy int
+
+const (
+	// PathStrict
+	// match the request path as it is, the default
+	PathStrict PathPolicy = iota
+	// PathRedirect
+	// when the request path does not match but its canonical path does,
+	// redirect to the canonical path, 301 for GET and HEAD, 308 otherwise
+	PathRedirect
+	// PathTolerant
+	// match the canonical path directly
+	PathTolerant
+)
+
+// cleanPath
+// the canonical path, without "//", "." and ".." segments or trailing '/'
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return path.Clean(p)
+}
+
+// routingPath
+// the path used for routing, and whether it is escaped.
+// the escaped path is only used when it has an encoded '/', such as "/files/a%2Fb",
+// so that the encoded '/' stays in one segment instead of splitting it;
+// the other escapes are decoded, so the static segments match however they are encoded,
+// and the captured params are unescaped after matching, see unescapeParams
+func routingPath(u *url.URL) (string, bool) {
+	if u.RawPath == "" {
+		return u.Path, false
+	}
+	escaped := u.EscapedPath()
+	if !strings.Contains(escaped, "%2F") && !strings.Contains(escaped, "%2f") {
+		return u.Path, false
+	}
+
+	// keep only '%' and '/' escaped in each segment
+	segs := strings.Split(escaped, "/")
+	for i, seg := range segs {
+		if v, err := url.PathUnescape(seg); err == nil {
+			segs[i] = segmentEscaper.Replace(v)
+		}
+	}
+	return strings.Join(segs, "/"), true
+}
+
+// segmentEscaper
+// escape what can not be decoded in a segment of the routing path, see routingPath
+var segmentEscaper = strings.NewReplacer("%", "%25", "/", "%2F")
+
+// unescapeParams
+// unescape the params captured from an escaped path in place
+// invalid escapes are kept as they are
+func unescapeParams(params Params) {
+	for i, p := range params {
+		if v, err := url.PathUnescape(p.Value); err == nil {
+			params[i].Value = v
+		}
+	}
+}
+
+// redirectCode
+// 301 may change the method to GET, so 308 for the others
+func redirectCode(method string) int {
+	if method == http.MethodGet || method == http.MethodHead {
+		return http.StatusMovedPermanently
+	}
+	return http.StatusPermanentRedirect
+}
+
+// isStaticSegment
+// static segment of a registered path, compared case-insensitively
+// when case-insensitive routing is enabled
+func isStaticSegment(seg string) bool {
+	return seg != "" && seg[0] != ':' && seg[0] != '*' && !isPatternSegment(seg)
+}
+
+// isParamSegment
+// plain param segment, such as ":id", but not ":id(.*)" or ":name.:ext"
+func isParamSegment(seg string) bool {
+	return seg != "" && seg[0] == ':' && !strings.ContainsAny(seg, "()") && !isPatternSegment(seg)
+}
+
+// caseDiffers
+// report whether the static segments of reqPath differ from route in casing,
+// reqPath must be matched by route
+func caseDiffers(route string, reqPath string) bool {
+	if route == "/" {
+		return false
+	}
+	routeRest, reqRest := route[1:], reqPath[1:]
+	for {
+		routeSeg, routeNext, routeLast := cutSegment(routeRest)
+		reqSeg, reqNext, reqLast := cutSegment(reqRest)
+		if isStaticSegment(routeSeg) && routeSeg != reqSeg {
+			return true
+		}
+		if routeLast || reqLast {
+			return false
+		}
+		routeRest, reqRest = routeNext, reqNext
+	}
+}
+
+// canonicalCase
+// rebuild reqPath with the casing of the static segments of route,
+// reqPath must be matched by route
+func canonicalCase(route string, reqPath string) string {
+	if route == "/" {
+		return route
+	}
+
+	var sb strings.Builder
+	routeRest, reqRest := route[1:], reqPath[1:]
+	for {
+		routeSeg, routeNext, routeLast := cutSegment(routeRest)
+		reqSeg, reqNext, reqLast := cutSegment(reqRest)
+		sb.WriteByte('/')
+		// tail wild card and catch-all keep all the rest segments
+		if routeLast && routeSeg[0] == '*' {
+			sb.WriteString(reqRest)
+			return sb.String()
+		}
+
+		if isStaticSegment(routeSeg) {
+			sb.WriteString(routeSeg)
+		} else {
+			sb.WriteString(reqSeg)
+		}
+		if routeLast || reqLast {
+			return sb.String()
+		}
+		routeRest, reqRest = routeNext, reqNext
+	}
+}
@@ -0,0 +1,316 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPServer_PathPolicy(t *testing.T) {
+	newServer := func(policy PathPolicy) *HTTPServer {
+		s := NewHTTPServer(ServerWithPathPolicy(policy))
+		s.Get("/user", func(ctx *Context) {
+			_, _ = ctx.Resp.Write([]byte("user"))
+		})
+		s.Post("/user", func(ctx *Context) {
+			_, _ = ctx.Resp.Write([]byte("create user"))
+		})
+		return s
+	}
+
+	testCases := []struct {
+		name           string
+		policy         PathPolicy
+		method         string
+		target         string
+		expectCode     int
+		expectBody     string
+		expectLocation string
+	}{
+		{
+			name:       "strict canonical",
+			policy:     PathStrict,
+			method:     http.MethodGet,
+			target:     "/user",
+			expectCode: http.StatusOK,
+			expectBody: "user",
+		},
+		{
+			name:       "strict trailing slash",
+			policy:     PathStrict,
+			method:     http.MethodGet,
+			target:     "/user/",
+			expectCode: http.StatusNotFound,
+			expectBody: "NOT FOUND",
+		},
+		{
+			name:           "redirect trailing slash",
+			policy:         PathRedirect,
+			method:         http.MethodGet,
+			target:         "/user/?name=Tom",
+			expectCode:     http.StatusMovedPermanently,
+			expectLocation: "/user?name=Tom",
+		},
+		{
+			name:           "redirect double slash with post",
+			policy:         PathRedirect,
+			method:         http.MethodPost,
+			target:         "//user",
+			expectCode:     http.StatusPermanentRedirect,
+			expectLocation: "/user",
+		},
+		{
+			name:           "redirect dot dot",
+			policy:         PathRedirect,
+			method:         http.MethodGet,
+			target:         "/user/../user",
+			expectCode:     http.StatusMovedPermanently,
+			expectLocation: "/user",
+		},
+		{
+			name:       "redirect canonical not found",
+			policy:     PathRedirect,
+			method:     http.MethodGet,
+			target:     "/order/",
+			expectCode: http.StatusNotFound,
+			expectBody: "NOT FOUND",
+		},
+		{
+			name:       "tolerant trailing slash",
+			policy:     PathTolerant,
+			method:     http.MethodGet,
+			target:     "/user/",
+			expectCode: http.StatusOK,
+			expectBody: "user",
+		},
+		{
+			name:       "tolerant dot dot",
+			policy:     PathTolerant,
+			method:     http.MethodPost,
+			target:     "/user/./../user",
+			expectCode: http.StatusOK,
+			expectBody: "create user",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newServer(tc.policy)
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+			assert.Equal(t, tc.expectLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHTTPServer_EscapedParams(t *testing.T) {
+	s := NewHTTPServer()
+	s.Get("/files/:name", func(ctx *Context) {
+		name, _ := ctx.PathParams.Get("name")
+		_, _ = ctx.Resp.Write([]byte("file " + name))
+	})
+	s.Get("/static/*filepath", func(ctx *Context) {
+		filepath, _ := ctx.PathParams.Get("filepath")
+		_, _ = ctx.Resp.Write([]byte("static " + filepath))
+	})
+	s.Get("/café", func(ctx *Context) {
+		_, _ = ctx.Resp.Write([]byte("café"))
+	})
+	s.Get("/user/home", func(ctx *Context) {
+		_, _ = ctx.Resp.Write([]byte("user home"))
+	})
+	s.Get("/user/:name", func(ctx *Context) {
+		name, _ := ctx.PathParams.Get("name")
+		_, _ = ctx.Resp.Write([]byte("user " + name))
+	})
+
+	testCases := []struct {
+		name       string
+		target     string
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "encoded slash stays in param",
+			target:     "/files/a%2Fb",
+			expectCode: http.StatusOK,
+			expectBody: "file a/b",
+		},
+		{
+			name:       "encoded space",
+			target:     "/files/a%20b",
+			expectCode: http.StatusOK,
+			expectBody: "file a b",
+		},
+		{
+			name:       "encoded slash in catch-all",
+			target:     "/static/css/a%2Fb.css",
+			expectCode: http.StatusOK,
+			expectBody: "static css/a/b.css",
+		},
+		{
+			name:       "lowercase hex in static segment",
+			target:     "/caf%c3%a9",
+			expectCode: http.StatusOK,
+			expectBody: "café",
+		},
+		{
+			name:       "encoded static segment",
+			target:     "/%75ser/home",
+			expectCode: http.StatusOK,
+			expectBody: "user home",
+		},
+		{
+			name:       "encoded static segment with encoded slash",
+			target:     "/%75ser/a%2fb",
+			expectCode: http.StatusOK,
+			expectBody: "user a/b",
+		},
+		{
+			name:       "encoded percent with encoded slash",
+			target:     "/files/a%2F%2541",
+			expectCode: http.StatusOK,
+			expectBody: "file a/%41",
+		},
+		{
+			name:       "unencoded slash splits",
+			target:     "/files/a/b",
+			expectCode: http.StatusNotFound,
+			expectBody: "NOT FOUND",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestHTTPServer_CaseInsensitive(t *testing.T) {
+	register := func(s *HTTPServer) {
+		s.Get("/order/detail", func(ctx *Context) {
+			_, _ = ctx.Resp.Write([]byte("order detail"))
+		})
+		s.Get("/order/:id/Items", func(ctx *Context) {
+			id, _ := ctx.PathParams.Get("id")
+			_, _ = ctx.Resp.Write([]byte("items of " + id))
+		})
+		s.Get("/static/*filepath", func(ctx *Context) {
+			filepath, _ := ctx.PathParams.Get("filepath")
+			_, _ = ctx.Resp.Write([]byte("static " + filepath))
+		})
+	}
+
+	testCases := []struct {
+		name           string
+		opts           []HTTPServerOption
+		target         string
+		expectCode     int
+		expectBody     string
+		expectLocation string
+	}{
+		{
+			name:       "case-sensitive by default",
+			target:     "/Order/Detail",
+			expectCode: http.StatusNotFound,
+			expectBody: "NOT FOUND",
+		},
+		{
+			name:       "serve directly",
+			opts:       []HTTPServerOption{ServerWithCaseInsensitive(false)},
+			target:     "/Order/Detail",
+			expectCode: http.StatusOK,
+			expectBody: "order detail",
+		},
+		{
+			name:       "param keeps its casing",
+			opts:       []HTTPServerOption{ServerWithCaseInsensitive(false)},
+			target:     "/ORDER/AbC/items",
+			expectCode: http.StatusOK,
+			expectBody: "items of AbC",
+		},
+		{
+			name:           "redirect",
+			opts:           []HTTPServerOption{ServerWithCaseInsensitive(true)},
+			target:         "/Order/Detail?from=mail",
+			expectCode:     http.StatusMovedPermanently,
+			expectLocation: "/order/detail?from=mail",
+		},
+		{
+			name:           "redirect keeps param and catch-all casing",
+			opts:           []HTTPServerOption{ServerWithCaseInsensitive(true)},
+			target:         "/ORDER/AbC/ITEMS",
+			expectCode:     http.StatusMovedPermanently,
+			expectLocation: "/order/AbC/Items",
+		},
+		{
+			name:           "redirect catch-all",
+			opts:           []HTTPServerOption{ServerWithCaseInsensitive(true)},
+			target:         "/Static/CSS/Main.css",
+			expectCode:     http.StatusMovedPermanently,
+			expectLocation: "/static/CSS/Main.css",
+		},
+		{
+			name:       "canonical casing is served",
+			opts:       []HTTPServerOption{ServerWithCaseInsensitive(true)},
+			target:     "/order/detail",
+			expectCode: http.StatusOK,
+			expectBody: "order detail",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewHTTPServer(tc.opts...)
+			register(s)
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+			assert.Equal(t, tc.expectLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRouter_findRouteExactBeforeFold(t *testing.T) {
+	r := newRouter()
+	r.caseInsensitive = true
+	r.addRoute(http.MethodGet, "/user", func(ctx *Context) {})
+	r.addRoute(http.MethodGet, "/User/home", func(ctx *Context) {})
+
+	info, found := r.findRoute(http.MethodGet, "/user", nil)
+	assert.True(t, found)
+	assert.Equal(t, "/user", info.n.route)
+
+	// exact "user" is a dead end, falls back to "User"
+	info, found = r.findRoute(http.MethodGet, "/user/home", nil)
+	assert.True(t, found)
+	assert.Equal(t, "/User/home", info.n.route)
+}
+
+func TestRouter_findRouteFoldOrder(t *testing.T) {
+	for _, r := range []*router{newRouter(), newRadixRouter()} {
+		r.caseInsensitive = true
+		r.addRoute(http.MethodGet, "/Order", func(ctx *Context) {})
+		r.addRoute(http.MethodGet, "/ORDER", func(ctx *Context) {})
+		r.addRoute(http.MethodGet, "/oRDER", func(ctx *Context) {})
+
+		// the keys differing only in case are tried in sorted order, not map order
+		for i := 0; i < 50; i++ {
+			info, found := r.findRoute(http.MethodGet, "/order", nil)
+			assert.True(t, found)
+			assert.Equal(t, "/ORDER", info.n.route)
+		}
+	}
+}
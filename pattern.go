@@ -0,0 +1,153 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+)
+
+// patternPart
+// a part of a pattern segment, either a literal or a param
+// for example, ":name.:ext" is parsed into [name] [.] [ext]
+type patternPart struct {
+	literal string
+	param   string
+}
+
+// isPatternSegment
+// a segment with ':' not at the beginning, or with more than one ':',
+// such as ":name.:ext" or "v:version"
+// ":id" and ":id(regex)" are handled by paramChild and regChild,
+// only the text before '(' of a regex segment is scanned, so ':' in the regex does not count
+func isPatternSegment(seg string) bool {
+	if seg[0] == ':' && seg[len(seg)-1] == ')' {
+		if i := strings.IndexByte(seg, '('); i >= 0 {
+			seg = seg[:i]
+		}
+	}
+	return strings.IndexByte(seg[1:], ':') >= 0 || (seg[0] != ':' && strings.IndexByte(seg, ':') > 0)
+}
+
+// parsePattern
+// a param name is made of letters, digits and '_',
+// the literal after it starts from the first other character
+func parsePattern(seg string) ([]patternPart, error) {
+	if strings.ContainsAny(seg, "()*") {
+		return nil, fmt.Errorf("[%s] regex and wild card are not supported in pattern segment", seg)
+	}
+
+	var parts []patternPart
+	for seg != "" {
+		if seg[0] != ':' {
+			end := strings.IndexByte(seg, ':')
+			if end < 0 {
+				end = len(seg)
+			}
+			parts = append(parts, patternPart{literal: seg[:end]})
+			seg = seg[end:]
+			continue
+		}
+
+		end := 1
+		for end < len(seg) && isParamNameChar(seg[end]) {
+			end++
+		}
+		if end == 1 {
+			return nil, fmt.Errorf("[%s] param name can not be empty", seg)
+		}
+		// two params in a row can not be told apart when matching
+		if len(parts) > 0 && parts[len(parts)-1].param != "" {
+			return nil, fmt.Errorf("[%s] params must be separated by literal", seg)
+		}
+		parts = append(parts, patternPart{param: seg[1:end]})
+		seg = seg[end:]
+	}
+	return parts, nil
+}
+
+func isParamNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// patternShape
+// the pattern with param names dropped, such as ":.:" for ":name.:ext"
+// patterns of the same shape match the same segments, so they conflict
+func patternShape(parts []patternPart) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		if part.param != "" {
+			sb.WriteByte(':')
+			continue
+		}
+		sb.WriteString(part.literal)
+	}
+	return sb.String()
+}
+
+// literalLen
+// total length of the literals, longer ones are more specific
+func literalLen(parts []patternPart) int {
+	l := 0
+	for _, part := range parts {
+		l += len(part.literal)
+	}
+	return l
+}
+
+// lookupPatternChild
+// patterns of the same shape with different names conflict
+func (n *node) lookupPatternChild(seg string) (child *node, conflict *node) {
+	parts, _ := parsePattern(seg)
+	shape := patternShape(parts)
+	for _, child := range n.patternChildren {
+		if child.path == seg {
+			return child, nil
+		}
+		if patternShape(child.pattern) == shape {
+			return nil, child
+		}
+	}
+	return nil, nil
+}
+
+// createPatternChild
+// keep patternChildren ordered by literal length, the more specific first
+func (n *node) createPatternChild(seg string) *node {
+	parts, _ := parsePattern(seg)
+	child := &node{path: seg, pattern: parts}
+	i := 0
+	for i < len(n.patternChildren) && literalLen(n.patternChildren[i].pattern) >= literalLen(parts) {
+		i++
+	}
+	n.patternChildren = append(n.patternChildren, nil)
+	copy(n.patternChildren[i+1:], n.patternChildren[i:])
+	n.patternChildren[i] = child
+	return child
+}
+
+// matchPattern
+// match seg against the pattern of n, append the captured params
+// a param which is not the last part ends at the first occurrence of the next literal,
+// so ":name.:ext" matches "a.tar.gz" with name "a" and ext "tar.gz"
+// params never capture empty value
+func (n *node) matchPattern(seg string, params Params) (Params, bool) {
+	for i, part := range n.pattern {
+		if part.param == "" {
+			if !strings.HasPrefix(seg, part.literal) {
+				return params, false
+			}
+			seg = seg[len(part.literal):]
+			continue
+		}
+
+		end := len(seg)
+		if i < len(n.pattern)-1 {
+			end = strings.Index(seg, n.pattern[i+1].literal)
+		}
+		if end <= 0 {
+			return params, false
+		}
+		params = append(params, Param{Key: part.param, Value: seg[:end]})
+		seg = seg[end:]
+	}
+	return params, seg == ""
+}
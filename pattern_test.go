@@ -0,0 +1,175 @@
+package web
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePattern(t *testing.T) {
+	testCases := []struct {
+		name   string
+		seg    string
+		expect []patternPart
+	}{
+		{
+			name:   "name and ext",
+			seg:    ":name.:ext",
+			expect: []patternPart{{param: "name"}, {literal: "."}, {param: "ext"}},
+		},
+		{
+			name:   "literal prefix",
+			seg:    "v:version",
+			expect: []patternPart{{literal: "v"}, {param: "version"}},
+		},
+		{
+			name: "literal suffix",
+			seg:  "page-:num.html",
+			expect: []patternPart{
+				{literal: "page-"}, {param: "num"}, {literal: ".html"},
+			},
+		},
+		{
+			name: "three params",
+			seg:  ":y-:m-:d",
+			expect: []patternPart{
+				{param: "y"}, {literal: "-"}, {param: "m"}, {literal: "-"}, {param: "d"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, isPatternSegment(tc.seg))
+			parts, err := parsePattern(tc.seg)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, parts)
+		})
+	}
+
+	// TEST: not pattern segments
+	for _, seg := range []string{"user", ":id", ":id(^[0-9]+$)", "*", "*filepath"} {
+		assert.False(t, isPatternSegment(seg), seg)
+	}
+
+	// TEST: invalid patterns
+	for _, seg := range []string{"v:", ":a:b", "v:id(^[0-9]+$)", "a:b*"} {
+		_, err := parsePattern(seg)
+		assert.Error(t, err, seg)
+	}
+}
+
+func TestRouter_findRoutePattern(t *testing.T) {
+	testRouter := newRouter()
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	for _, path := range []string{
+		"/files/:name.:ext",
+		"/files/:name.tar.:zip",
+		"/files/readme.md",
+		"/files/:file",
+		"/v:version/users",
+		"/date/:y-:m-:d",
+	} {
+		testRouter.addRoute(http.MethodGet, path, fakeHandleFunc)
+	}
+
+	testCases := []struct {
+		name         string
+		path         string
+		expect       bool
+		expectNode   string
+		expectParams Params
+	}{
+		{
+			name:         "name and ext",
+			path:         "/files/photo.png",
+			expect:       true,
+			expectNode:   ":name.:ext",
+			expectParams: Params{{Key: "name", Value: "photo"}, {Key: "ext", Value: "png"}},
+		},
+		{
+			name:         "more specific pattern first",
+			path:         "/files/backup.tar.gz",
+			expect:       true,
+			expectNode:   ":name.tar.:zip",
+			expectParams: Params{{Key: "name", Value: "backup"}, {Key: "zip", Value: "gz"}},
+		},
+		{
+			name:         "first occurrence of literal",
+			path:         "/files/a.b.c",
+			expect:       true,
+			expectNode:   ":name.:ext",
+			expectParams: Params{{Key: "name", Value: "a"}, {Key: "ext", Value: "b.c"}},
+		},
+		{
+			name:       "static wins over pattern",
+			path:       "/files/readme.md",
+			expect:     true,
+			expectNode: "readme.md",
+		},
+		{
+			name:         "pattern does not match, falls back to param",
+			path:         "/files/Makefile",
+			expect:       true,
+			expectNode:   ":file",
+			expectParams: Params{{Key: "file", Value: "Makefile"}},
+		},
+		{
+			name:         "empty param falls back to param",
+			path:         "/files/.gitignore",
+			expect:       true,
+			expectNode:   ":file",
+			expectParams: Params{{Key: "file", Value: ".gitignore"}},
+		},
+		{
+			name:         "literal prefix",
+			path:         "/v2/users",
+			expect:       true,
+			expectNode:   "users",
+			expectParams: Params{{Key: "version", Value: "2"}},
+		},
+		{
+			name:   "literal prefix not matched",
+			path:   "/x2/users",
+			expect: false,
+		},
+		{
+			name:       "three params",
+			path:       "/date/2022-12-01",
+			expect:     true,
+			expectNode: ":y-:m-:d",
+			expectParams: Params{
+				{Key: "y", Value: "2022"}, {Key: "m", Value: "12"}, {Key: "d", Value: "01"},
+			},
+		},
+		{
+			name:   "missing part",
+			path:   "/date/2022-12",
+			expect: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, found := testRouter.findRoute(http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.expect, found)
+			if !found {
+				return
+			}
+			assert.Equal(t, tc.expectNode, info.n.path)
+			assert.Equal(t, tc.expectParams, info.pathParams)
+		})
+	}
+
+	// TEST: same shape with different names
+	r := newRouter()
+	r.addRoute(http.MethodGet, "/files/:name.:ext", fakeHandleFunc)
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/files/:base.:suffix", fakeHandleFunc)
+	})
+	// the same pattern is reused
+	assert.NotPanics(t, func() {
+		r.addRoute(http.MethodGet, "/files/:name.:ext/meta", fakeHandleFunc)
+	})
+}
@@ -0,0 +1,210 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// radixEdge
+// an edge of the radix tree holding the static children of a node, see newRadixRouter
+// a static child is keyed by the first segment of its path,
+// keys sharing a prefix share the edges of it, such as "user" and "users",
+// and the children of an edge are indexed by their first byte
+type radixEdge struct {
+	label string
+	// first bytes of children, in the same sorted order
+	indices  string
+	children []*radixEdge
+	// the static child whose key ends at this edge
+	node *node
+}
+
+// lookup
+// the static child of key, nil if there is none
+func (e *radixEdge) lookup(key string) *node {
+	for {
+		if !strings.HasPrefix(key, e.label) {
+			return nil
+		}
+		key = key[len(e.label):]
+		if key == "" {
+			return e.node
+		}
+
+		i := strings.IndexByte(e.indices, key[0])
+		if i < 0 {
+			return nil
+		}
+		e = e.children[i]
+	}
+}
+
+// set
+// set child as the static child of key, replace the existing one
+func (e *radixEdge) set(key string, child *node) {
+	for {
+		// common prefix of key and label
+		i := 0
+		for i < len(key) && i < len(e.label) && key[i] == e.label[i] {
+			i++
+		}
+
+		// split the edge at the end of the common prefix,
+		// such as "users" into "user" -> "s" when setting "user"
+		if i < len(e.label) {
+			tail := &radixEdge{label: e.label[i:], indices: e.indices, children: e.children, node: e.node}
+			e.label = e.label[:i]
+			e.indices = tail.label[:1]
+			e.children = []*radixEdge{tail}
+			e.node = nil
+		}
+
+		key = key[i:]
+		if key == "" {
+			e.node = child
+			return
+		}
+
+		j := strings.IndexByte(e.indices, key[0])
+		if j < 0 {
+			e.addChild(&radixEdge{label: key, node: child})
+			return
+		}
+		e = e.children[j]
+	}
+}
+
+// addChild
+// keep children sorted by first byte, so the keys are visited in order
+func (e *radixEdge) addChild(child *radixEdge) {
+	i := sort.Search(len(e.indices), func(i int) bool {
+		return e.indices[i] > child.label[0]
+	})
+	e.indices = e.indices[:i] + child.label[:1] + e.indices[i:]
+	e.children = append(e.children, nil)
+	copy(e.children[i+1:], e.children[i:])
+	e.children[i] = child
+}
+
+// each
+// call fn with the static children in key order, stop once fn returns true
+func (e *radixEdge) each(fn func(child *node) bool) bool {
+	if e.node != nil && fn(e.node) {
+		return true
+	}
+	for _, c := range e.children {
+		if c.each(fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// eachFold
+// call fn with the static children whose key equals key case-insensitively,
+// in key order, stop once fn returns true
+func (e *radixEdge) eachFold(key string, fn func(child *node) bool) bool {
+	if len(key) < len(e.label) || !strings.EqualFold(key[:len(e.label)], e.label) {
+		return false
+	}
+	key = key[len(e.label):]
+	if key == "" {
+		return e.node != nil && fn(e.node)
+	}
+
+	for _, c := range e.children {
+		if c.eachFold(key, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// staticChild
+// the static child keyed by seg, the first segment of its path
+func (n *node) staticChild(seg string) *node {
+	if n.static != nil {
+		return n.static.lookup(seg)
+	}
+	// lookup in a nil map is fine
+	return n.children[seg]
+}
+
+// setStaticChild
+// set child as the static child of seg,
+// in the radix tree for radix, or in the children map otherwise
+func (n *node) setStaticChild(seg string, child *node, radix bool) {
+	if radix {
+		if n.static == nil {
+			n.static = &radixEdge{}
+		}
+		n.static.set(seg, child)
+		return
+	}
+
+	if n.children == nil {
+		n.children = map[string]*node{}
+	}
+	if _, ok := n.children[seg]; !ok {
+		i := sort.SearchStrings(n.childKeys, seg)
+		n.childKeys = append(n.childKeys, "")
+		copy(n.childKeys[i+1:], n.childKeys[i:])
+		n.childKeys[i] = seg
+	}
+	n.children[seg] = child
+}
+
+// eachStatic
+// call fn with the static children in key order, stop once fn returns true
+func (n *node) eachStatic(fn func(child *node) bool) {
+	if n.static != nil {
+		n.static.each(fn)
+		return
+	}
+	for _, key := range n.childKeys {
+		if fn(n.children[key]) {
+			return
+		}
+	}
+}
+
+// onlyStaticChild
+// the static child if n has exactly one, nil otherwise
+func (n *node) onlyStaticChild() *node {
+	var only *node
+	count := 0
+	n.eachStatic(func(child *node) bool {
+		only = child
+		count++
+		return count > 1
+	})
+	if count != 1 {
+		return nil
+	}
+	return only
+}
+
+// matchStaticFold
+// try the static children whose key equals seg case-insensitively, in key order,
+// the one of seg itself is already tried by the exact lookup
+func (n *node) matchStaticFold(path string, seg string, params Params, req *http.Request) (res *node, ps Params, handler HandleFunc) {
+	try := func(child *node) bool {
+		if key, _, _ := cutSegment(child.path); key == seg {
+			return false
+		}
+		res, ps, handler = child.matchStatic(path, params, true, req)
+		return handler != nil
+	}
+
+	if n.static != nil {
+		n.static.eachFold(seg, try)
+		return res, ps, handler
+	}
+	for _, key := range n.childKeys {
+		if strings.EqualFold(key, seg) && try(n.children[key]) {
+			break
+		}
+	}
+	return res, ps, handler
+}
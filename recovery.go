@@ -0,0 +1,49 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery
+// catch panics from the following middlewares and handler, then:
+// 1. call onPanic with the panic value and stack trace, for metrics or alerts,
+// or log them by the logger of HTTPServer if onPanic is nil
+// 2. respond by Context.Error, unless the headers were already sent
+//
+// onPanic can be nil.
+// http.ErrAbortHandler is panicked again, net/http uses it to abort a response
+func Recovery(onPanic func(ctx *Context, err any, stack []byte)) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				stack := debug.Stack()
+				if onPanic != nil {
+					onPanic(ctx, err, stack)
+				} else {
+					logger := ctx.logger
+					if logger == nil {
+						logger = log.Default()
+					}
+					logger.Printf("web: panic: %v\n%s", err, stack)
+				}
+
+				if ctx.Written() {
+					return
+				}
+				ctx.Error(fmt.Errorf("panic: %v", err))
+			}()
+			next(ctx)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecovery(t *testing.T) {
+	var (
+		panicErr   any
+		panicStack []byte
+	)
+	s := NewHTTPServer()
+	s.Use(Recovery(func(ctx *Context, err any, stack []byte) {
+		panicErr = err
+		panicStack = stack
+	}))
+	s.Get("/panic", func(ctx *Context) {
+		panic("boom")
+	})
+	s.Get("/panic/after/write", func(ctx *Context) {
+		ctx.Resp.WriteHeader(http.StatusAccepted)
+		_, _ = ctx.Resp.Write([]byte("partial"))
+		panic("boom after write")
+	})
+	s.Get("/ok", func(ctx *Context) {
+		_, _ = ctx.Resp.Write([]byte("ok"))
+	})
+
+	testCases := []struct {
+		name       string
+		path       string
+		expectErr  any
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "panic",
+			path:       "/panic",
+			expectErr:  "boom",
+			expectCode: http.StatusInternalServerError,
+			expectBody: "INTERNAL SERVER ERROR",
+		},
+		{
+			name:       "panic after headers sent",
+			path:       "/panic/after/write",
+			expectErr:  "boom after write",
+			expectCode: http.StatusAccepted,
+			expectBody: "partial",
+		},
+		{
+			name:       "no panic",
+			path:       "/ok",
+			expectCode: http.StatusOK,
+			expectBody: "ok",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			panicErr, panicStack = nil, nil
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+			assert.Equal(t, tc.expectErr, panicErr)
+			if tc.expectErr != nil {
+				assert.Contains(t, string(panicStack), "recovery_test.go")
+			}
+		})
+	}
+
+	// TEST: http.ErrAbortHandler is not recovered
+	s.Get("/abort", func(ctx *Context) {
+		panic(http.ErrAbortHandler)
+	})
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		req := httptest.NewRequest(http.MethodGet, "/abort", nil)
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
+
+func TestRecovery_log(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	s := NewHTTPServer(ServerWithLogger(log.New(buffer, "", 0)))
+	s.Use(Recovery(nil))
+	s.Get("/panic", func(ctx *Context) {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, buffer.String(), "web: panic: boom")
+	assert.Contains(t, buffer.String(), "recovery_test.go")
+}
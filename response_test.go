@@ -0,0 +1,42 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseWriter_Hijack(t *testing.T) {
+	written := make(chan bool, 1)
+	s := NewHTTPServer()
+	s.Get("/raw", func(ctx *Context) {
+		hijacker, ok := ctx.Resp.(http.Hijacker)
+		require.True(t, ok)
+		conn, rw, err := hijacker.Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		written <- ctx.Written()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 3\r\nConnection: close\r\n\r\nraw")
+		_ = rw.Flush()
+	})
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/raw")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "raw", string(body))
+	assert.True(t, <-written)
+
+	// TEST: the underlying writer does not support hijacking
+	w := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	_, _, err = w.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+	assert.False(t, w.wroteHeader)
+}
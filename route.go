@@ -0,0 +1,153 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Route
+// a registered route, returned by HTTPServer.Get etc.
+// for example:
+//
+//	server.Get("/order/:id", handleFunc).Name("order")
+//	path, err := server.URLFor("order", map[string]string{"id": "1"}, nil) // /order/1
+type Route struct {
+	Method string
+	// the registered path, with optional segments such as ":year?"
+	Path string
+
+	name   string
+	router *router
+
+	// for introspection, see RouteInfo
+	handlerName string
+	middlewares []string
+	matchers    []string
+
+	// regex of the regex segments, compiled at registration, checked by URLFor
+	regExprs map[string]*regexp.Regexp
+}
+
+// setRegExpr
+// keep the regex of seg compiled by the tree
+func (rt *Route) setRegExpr(seg string, expr *regexp.Regexp) {
+	if rt.regExprs == nil {
+		rt.regExprs = map[string]*regexp.Regexp{}
+	}
+	rt.regExprs[seg] = expr
+}
+
+// Name
+// name the route for URLFor, a name can only be used once
+func (rt *Route) Name(name string) *Route {
+	if exist, ok := rt.router.named[name]; ok {
+		panic(fmt.Sprintf("Route Name Error: [%s] already used by %s %s", name, exist.Method, exist.Path))
+	}
+	rt.name = name
+	rt.router.named[name] = rt
+	return rt
+}
+
+// URLFor
+// build the path of the route named name, with query appended if not empty
+// params fill the segments of the route:
+// - ":id" and ":name.:ext", by the param names
+// - ":id(^[0-9]+$)", the value must match the regex
+// - "*filepath", by "filepath", '/' in the value is kept
+// - "*", by "*"
+// an optional segment is dropped when its params are absent
+func (r *router) URLFor(name string, params map[string]string, query url.Values) (string, error) {
+	rt, ok := r.named[name]
+	if !ok {
+		return "", fmt.Errorf("web: route %s not found", name)
+	}
+
+	var sb strings.Builder
+	for _, seg := range strings.Split(rt.Path[1:], "/") {
+		if seg == "" {
+			continue
+		}
+		optional := len(seg) > 1 && seg[len(seg)-1] == '?'
+		seg = strings.TrimSuffix(seg, "?")
+
+		value, ok, err := rt.buildSegment(seg, params)
+		if err != nil {
+			return "", fmt.Errorf("web: route %s: %w", name, err)
+		}
+		if !ok {
+			if optional {
+				continue
+			}
+			return "", fmt.Errorf("web: route %s: param of %s is required", name, seg)
+		}
+		sb.WriteByte('/')
+		sb.WriteString(value)
+	}
+
+	path := sb.String()
+	if path == "" {
+		path = "/"
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// buildSegment
+// fill seg with params, report false if any param is absent
+func (rt *Route) buildSegment(seg string, params map[string]string) (string, bool, error) {
+	switch {
+	case seg[0] == '*':
+		key := seg[1:]
+		if key == "" {
+			key = "*"
+		}
+		value, ok := params[key]
+		if !ok || value == "" {
+			return "", false, nil
+		}
+		parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+		return strings.Join(parts, "/"), true, nil
+
+	case isPatternSegment(seg):
+		parts, err := parsePattern(seg)
+		if err != nil {
+			return "", false, err
+		}
+		var sb strings.Builder
+		for _, part := range parts {
+			if part.param == "" {
+				sb.WriteString(part.literal)
+				continue
+			}
+			value, ok := params[part.param]
+			if !ok || value == "" {
+				return "", false, nil
+			}
+			sb.WriteString(url.PathEscape(value))
+		}
+		return sb.String(), true, nil
+
+	case seg[0] == ':':
+		key := seg[1:]
+		if i := strings.IndexByte(seg, '('); i >= 0 {
+			key = seg[1:i]
+		}
+		value, ok := params[key]
+		if !ok || value == "" {
+			return "", false, nil
+		}
+		if expr := rt.regExprs[seg]; expr != nil && !expr.MatchString(value) {
+			return "", false, fmt.Errorf("param %s=%s does not match %s", key, value, expr)
+		}
+		return url.PathEscape(value), true, nil
+	}
+
+	return seg, true, nil
+}
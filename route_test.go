@@ -0,0 +1,140 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPServer_URLFor(t *testing.T) {
+	handler := func(ctx *Context) {}
+	s := NewHTTPServer()
+	s.Get("/", handler).Name("home")
+	s.Get("/order/detail", handler).Name("order detail")
+	s.Get("/order/:id", handler).Name("order")
+	s.Get("/user/:id(^[0-9]+$)/profile", handler).Name("user profile")
+	s.Get("/files/:name.:ext", handler).Name("file")
+	s.Get("/static/*filepath", handler).Name("static")
+	s.Get("/test/*/a", handler).Name("wild card")
+	s.Get("/reports/:year?", handler).Name("reports")
+	s.Group("/api/v1").Post("/user/:id", handler).Name("api user")
+
+	testCases := []struct {
+		name      string
+		route     string
+		params    map[string]string
+		query     url.Values
+		expect    string
+		expectErr bool
+	}{
+		{
+			name:   "root",
+			route:  "home",
+			expect: "/",
+		},
+		{
+			name:   "static",
+			route:  "order detail",
+			expect: "/order/detail",
+		},
+		{
+			name:   "param",
+			route:  "order",
+			params: map[string]string{"id": "12"},
+			expect: "/order/12",
+		},
+		{
+			name:   "param escaped",
+			route:  "order",
+			params: map[string]string{"id": "a/b c"},
+			expect: "/order/a%2Fb%20c",
+		},
+		{
+			name:   "with query",
+			route:  "order",
+			params: map[string]string{"id": "12"},
+			query:  url.Values{"lang": []string{"en"}},
+			expect: "/order/12?lang=en",
+		},
+		{
+			name:      "param missing",
+			route:     "order",
+			expectErr: true,
+		},
+		{
+			name:   "regex",
+			route:  "user profile",
+			params: map[string]string{"id": "7"},
+			expect: "/user/7/profile",
+		},
+		{
+			name:      "regex rejects",
+			route:     "user profile",
+			params:    map[string]string{"id": "tom"},
+			expectErr: true,
+		},
+		{
+			name:   "pattern",
+			route:  "file",
+			params: map[string]string{"name": "photo", "ext": "png"},
+			expect: "/files/photo.png",
+		},
+		{
+			name:   "catch-all keeps slash",
+			route:  "static",
+			params: map[string]string{"filepath": "css/main theme.css"},
+			expect: "/static/css/main%20theme.css",
+		},
+		{
+			name:   "wild card",
+			route:  "wild card",
+			params: map[string]string{"*": "x"},
+			expect: "/test/x/a",
+		},
+		{
+			name:   "optional absent",
+			route:  "reports",
+			expect: "/reports",
+		},
+		{
+			name:   "optional present",
+			route:  "reports",
+			params: map[string]string{"year": "2022"},
+			expect: "/reports/2022",
+		},
+		{
+			name:   "group",
+			route:  "api user",
+			params: map[string]string{"id": "1"},
+			expect: "/api/v1/user/1",
+		},
+		{
+			name:      "unknown route",
+			route:     "unknown",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := s.URLFor(tc.route, tc.params, tc.query)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, path)
+		})
+	}
+
+	// TEST: the regex compiled by the tree is reused
+	user := s.trees[http.MethodGet].staticChild("user")
+	assert.Same(t, user.regChild.regExpr, s.named["user profile"].regExprs[":id(^[0-9]+$)"])
+
+	// TEST: a name can only be used once
+	assert.Panics(t, func() {
+		s.Get("/order/list", handler).Name("order")
+	})
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 )
@@ -9,6 +10,17 @@ import (
 type router struct {
 	// http method => tree
 	trees map[string]*node
+
+	// route name => route, see Route.Name
+	named map[string]*Route
+
+	// match static segments case-insensitively,
+	// when the exact one does not lead to a route
+	caseInsensitive bool
+
+	// hold static children in radix trees and merge static chains into one node,
+	// see newRadixRouter
+	compress bool
 }
 
 type node struct {
@@ -17,13 +29,31 @@ type node struct {
 	// or regex match string
 	path string
 
-	// used by paramChild and regChild
+	// used by paramChild, regChild and catch-all wildCardChild
 	pathParam string
 
-	handler  handleFunc
+	// used by regChild, compiled once at registration time
+	regExpr *regexp.Regexp
+
+	handler  HandleFunc
 	children map[string]*node // children path => children node
+	// keys of children in sorted order,
+	// so the case-insensitive fallback tries them in a fixed order
+	childKeys []string
+	// static children of a radix router, instead of children, see radixEdge
+	static *radixEdge
+
+	// routes with matchers, tried in registration order before handler,
+	// see HTTPServer.Match
+	matched []matchedRoute
+
+	// the full registered path, set on the node with handler
+	// optional segments are expanded, see Route.Path for the original one
+	route string
+	rt    *Route
 
 	// wild card child: /order/detail/*
+	// or catch-all child: /files/*filepath
 	wildCardChild *node
 
 	// parameter child: /order/detail/:id
@@ -31,27 +61,294 @@ type node struct {
 
 	// regex child: /reg/:id(^[0-9]+)
 	regChild *node
+
+	// pattern children: /files/:name.:ext, /v:version/users
+	patternChildren []*node
+	// used by patternChild
+	pattern []patternPart
 }
 
 func newRouter() *router {
 	return &router{
 		trees: map[string]*node{},
+		named: map[string]*Route{},
+	}
+}
+
+// newRadixRouter
+// a router compressing the static parts of the tree:
+// - the static children of a node are held in a radix tree indexed by first byte
+// instead of a map, keys sharing a prefix such as "user" and "users" share its edge
+// - chains of static segments without route are merged into one node,
+// such as "/api/v1/repos" in "/api/v1/repos/:owner" and "/api/v1/repos/search",
+// so they are matched by one string comparison instead of a lookup per segment
+// it holds the same routes and finds the same route as newRouter
+func newRadixRouter() *router {
+	r := newRouter()
+	r.compress = true
+	return r
+}
+
+// addRoute
+// the panicking variant of tryAddRoute
+func (r *router) addRoute(method string, path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	rt, err := r.tryAddRoute(method, path, handleFunc, mws...)
+	if err != nil {
+		panic(err)
 	}
+	return rt
 }
 
-func (r *router) addRoute(method string, path string, handleFunc handleFunc) {
+// tryAddRoute
+// the tree is left untouched when *RouteError is returned,
+// so the caller can go on with the other routes and report all the errors at once
+func (r *router) tryAddRoute(method string, path string, handleFunc HandleFunc, mws ...Middleware) (*Route, error) {
+	return r.tryAddMatchedRoute(method, path, nil, handleFunc, mws...)
+}
+
+// tryAddMatchedRoute
+// add a route which is only chosen when all of matchers match the request,
+// routes of the same path are duplicate only when they have the same matchers
+func (r *router) tryAddMatchedRoute(method string, path string, matchers []Matcher,
+	handleFunc HandleFunc, mws ...Middleware) (*Route, error) {
+	if reason := checkPath(path); reason != "" {
+		return nil, &RouteError{Err: ErrInvalidRoute, Method: method, Path: path, Reason: reason}
+	}
+
+	// expand optional segments, such as "/reports/:year?",
+	// into "/reports" and "/reports/:year"
+	// check all of them before inserting any
+	paths := expandOptional(path)
+	shapes := make(map[string]string, len(paths))
+	key := matchersKey(matchers)
+	for _, p := range paths {
+		if err := r.checkRoute(method, p, key); err != nil {
+			err.Path = path
+			return nil, err
+		}
+
+		// such as "/a/:x?/:y?", which expands "/a/:x" and "/a/:y"
+		shape := routeShape(p)
+		if exist, ok := shapes[shape]; ok {
+			return nil, &RouteError{
+				Err: ErrDuplicateRoute, Method: method, Path: path, Existing: exist,
+				Reason: fmt.Sprintf("optional segments expand to %s again", p),
+			}
+		}
+		shapes[shape] = p
+	}
+
+	// such as "/a/:x?/:y", which expands "/a/:y" and "/a/:x/:y"
+	params := make(map[string]string, len(paths))
+	for _, p := range paths {
+		if exist, seg := renamedParam(p, params); exist != "" {
+			return nil, &RouteError{
+				Err: ErrConflictingRoute, Method: method, Path: path, Existing: path,
+				Reason: fmt.Sprintf("%s and %s can not exist at the same time", exist, seg),
+			}
+		}
+	}
+
+	rt := &Route{
+		Method:      method,
+		Path:        path,
+		router:      r,
+		handlerName: funcName(handleFunc),
+		middlewares: make([]string, 0, len(mws)),
+	}
+	for _, mw := range mws {
+		rt.middlewares = append(rt.middlewares, funcName(mw))
+	}
+	for _, m := range matchers {
+		rt.matchers = append(rt.matchers, m.String())
+	}
+
+	// compose route level middlewares once at registration time,
+	// shared by the expanded paths
+	handleFunc = chain(handleFunc, mws)
+	for _, p := range paths {
+		r.insert(method, p, handleFunc, rt, matchers)
+	}
+	return rt, nil
+}
+
+// checkPath
+// return the reason why path is malformed, "" if it is fine
+func checkPath(path string) string {
 	if path == "" {
-		panic("Route Check Error: [path] can not be empty!")
+		return "[path] can not be empty"
 	}
 
 	if path[:1] != "/" {
-		panic("Route Check Error: [path] must be start with '/'!")
+		return "[path] must be start with '/'"
 	}
 
 	if len(path) > 1 && path[len(path)-1:] == "/" {
-		panic("Route Check Error: [path] last character can not be '/'!")
+		return "[path] last character can not be '/'"
+	}
+
+	if path == "/" {
+		return ""
 	}
 
+	segs := strings.Split(path[1:], "/")
+	for i, seg := range segs {
+		if seg == "" {
+			return "[path] can not use continue '/', such as '//'"
+		}
+		if seg[0] == '*' && len(seg) > 1 && i != len(segs)-1 {
+			return fmt.Sprintf("catch-all %s must be the last segment", seg)
+		}
+	}
+	return ""
+}
+
+// checkRoute
+// check path, which has no optional segment, against the tree of method
+// key identifies the matchers of the route, see matchersKey
+func (r *router) checkRoute(method string, path string, key string) *RouteError {
+	currentNode := r.trees[method]
+	// the segments of a compressed node after the matched ones, see compact
+	var pending string
+	// the param child renamed by path, such as ":uid" after ":id",
+	// which is a duplicate if the route exists, otherwise a conflict
+	var renamed *node
+	var renamedSeg string
+	if path != "/" {
+		for _, seg := range strings.Split(path[1:], "/") {
+			if err := checkSegment(seg); err != nil {
+				return &RouteError{Err: ErrInvalidRoute, Method: method, Reason: err.Error()}
+			}
+			// the rest segments are new, they can not conflict
+			if currentNode == nil {
+				continue
+			}
+
+			// inside a compressed node, the only child is the next pending segment
+			if pending != "" {
+				next, rest, _ := cutSegment(pending)
+				if seg != next {
+					currentNode = nil
+				}
+				pending = rest
+				continue
+			}
+
+			child, conflict := currentNode.lookupChild(seg)
+			if conflict != nil && conflict == currentNode.paramChild && isParamSegment(seg) {
+				if renamed == nil {
+					renamed, renamedSeg = conflict, seg
+				}
+				child, conflict = conflict, nil
+			}
+			if conflict != nil {
+				return &RouteError{
+					Err: ErrConflictingRoute, Method: method, Existing: conflict.anyRoute(),
+					Reason: fmt.Sprintf("%s and %s can not exist at the same time", conflict.path, seg),
+				}
+			}
+			currentNode = child
+			// only static children are compressed, a param child of a longer name is not
+			if child != nil && isStaticSegment(seg) && len(child.path) > len(seg) {
+				pending = child.path[len(seg)+1:]
+			}
+		}
+	}
+
+	if currentNode != nil && pending == "" {
+		if key == "" && currentNode.handler != nil {
+			return &RouteError{Err: ErrDuplicateRoute, Method: method, Existing: currentNode.route}
+		}
+		for _, mr := range currentNode.matched {
+			if matchersKey(mr.matchers) == key {
+				return &RouteError{Err: ErrDuplicateRoute, Method: method, Existing: mr.route, Reason: "with the same matchers"}
+			}
+		}
+	}
+	if renamed != nil {
+		return &RouteError{
+			Err: ErrConflictingRoute, Method: method, Existing: renamed.anyRoute(),
+			Reason: fmt.Sprintf("%s and %s can not exist at the same time", renamed.path, renamedSeg),
+		}
+	}
+	return nil
+}
+
+// checkSegment
+// check the syntax of regex and pattern segments
+func checkSegment(seg string) error {
+	if isPatternSegment(seg) {
+		_, err := parsePattern(seg)
+		return err
+	}
+	if seg[0] == ':' && strings.ContainsAny(seg, "()") {
+		_, _, err := parseRegexSegment(seg)
+		return err
+	}
+	return nil
+}
+
+// parseRegexSegment
+// get "username" and "(.*)" from ":username(.*)"
+func parseRegexSegment(seg string) (string, *regexp.Regexp, error) {
+	parts := strings.SplitN(seg, "(", 2)
+	if len(parts) != 2 || seg[len(seg)-1] != ')' {
+		return "", nil, fmt.Errorf("[%s] regex must be wrapped by '()'", seg)
+	}
+	expr, err := regexp.Compile("(" + parts[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("[%s] invalid regex: %v", seg, err)
+	}
+	return parts[0][1:], expr, nil
+}
+
+// routeShape
+// path with param names dropped, paths of the same shape are duplicate
+func routeShape(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if isParamSegment(seg) {
+			segs[i] = ":"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// renamedParam
+// the param of p named differently at the same position of the paths expanded before,
+// names maps the shape of a prefix to the name of its last param
+func renamedParam(p string, names map[string]string) (exist string, seg string) {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		if !isParamSegment(seg) {
+			continue
+		}
+		prefix := routeShape(strings.Join(segs[:i+1], "/"))
+		if exist, ok := names[prefix]; ok && exist != seg {
+			return exist, seg
+		}
+		names[prefix] = seg
+	}
+	return "", ""
+}
+
+// anyRoute
+// a registered route under n, or the path of n if there is none
+func (n *node) anyRoute() string {
+	route := n.path
+	found := false
+	n.walk(func(child *node) {
+		if !found {
+			route, found = child.route, true
+		}
+	})
+	return route
+}
+
+// insert
+// add the node of path to the tree of method,
+// path has no optional segment and is already checked by checkRoute
+func (r *router) insert(method string, path string, handleFunc HandleFunc, rt *Route, matchers []Matcher) {
 	currentNode, ok := r.trees[method]
 
 	// Create tree if not exist
@@ -62,222 +359,411 @@ func (r *router) addRoute(method string, path string, handleFunc handleFunc) {
 		r.trees[method] = currentNode
 	}
 
-	if path == "/" {
-		if currentNode.handler != nil {
-			panic("Route Add More Than One Time: [/] Already added")
+	// static nodes on the way, compacted from the deepest one
+	var static []*node
+	if path != "/" {
+		// Create child node if not exist
+		for _, seg := range strings.Split(path[1:], "/") {
+			currentNode = currentNode.childOrCreate(seg, r.compress)
+			if currentNode.regExpr != nil {
+				rt.setRegExpr(seg, currentNode.regExpr)
+			}
+			if r.compress && isStaticSegment(seg) {
+				static = append(static, currentNode)
+			}
+		}
+	}
+	// currentNode now is the last seg's node
+	currentNode.setHandler(path, handleFunc, rt, matchers)
+
+	for i := len(static) - 1; i >= 0; i-- {
+		static[i].compact()
+	}
+}
+
+// compact
+// merge static node n with its only child while n has no handler and no other children,
+// such as "api" -> "v1" -> "repos" into "api/v1/repos"
+// the compressed node is still keyed by its first segment in the parent's children
+func (n *node) compact() {
+	for !n.hasRoute() && n.paramChild == nil && n.regChild == nil &&
+		n.wildCardChild == nil && len(n.patternChildren) == 0 {
+		child := n.onlyStaticChild()
+		if child == nil {
+			return
+		}
+		merged := *child
+		merged.path = n.path + "/" + child.path
+		*n = merged
+	}
+}
+
+// split
+// split compressed node n after its first segment of size i,
+// return the node of the first segment, which takes the place of n
+func (n *node) split(i int) *node {
+	head := &node{path: n.path[:i]}
+	n.path = n.path[i+1:]
+	next, _, _ := cutSegment(n.path)
+	head.setStaticChild(next, n, true)
+	return head
+}
+
+func (n *node) setHandler(path string, handleFunc HandleFunc, rt *Route, matchers []Matcher) {
+	if len(matchers) > 0 {
+		n.matched = append(n.matched, matchedRoute{
+			matchers: matchers,
+			handler:  handleFunc,
+			route:    path,
+			rt:       rt,
+		})
+		if n.route == "" {
+			n.route = path
 		}
-		currentNode.handler = handleFunc
 		return
 	}
 
-	// remove first "/"
-	path2Split := path[1:]
+	n.handler = handleFunc
+	n.route = path
+	n.rt = rt
+}
 
-	// Create child node if not exist
-	segs := strings.Split(path2Split, "/")
-	for _, seg := range segs {
-		if seg == "" {
-			panic("Route Check Error: [path] can not use continue '/', such as '//'!")
+// hasRoute
+// report whether any route is registered on n, with or without matchers
+func (n *node) hasRoute() bool {
+	return n.handler != nil || len(n.matched) > 0
+}
+
+// handlerFor
+// the handler of the first route on n whose matchers all match req,
+// or the one without matchers, nil if there is none
+// req is nil to ignore the matchers
+func (n *node) handlerFor(req *http.Request) HandleFunc {
+	for _, mr := range n.matched {
+		if req == nil || matchAll(mr.matchers, req) {
+			return mr.handler
 		}
-		child := currentNode.childOrCreate(seg)
-		currentNode = child
 	}
-	// currentNode now is the last seg's node
-	if currentNode.handler != nil {
-		panic(fmt.Sprintf("Route Add More Than One Time: [%s] Already added", path))
+	return n.handler
+}
+
+// expandOptional
+// a segment ending with '?' is optional, such as ":year?", "*filepath?" or "latest?",
+// return the paths with and without each of them.
+// regex segment ends with ')', so "(a?)" is not optional but ":id(a)?" is
+func expandOptional(path string) []string {
+	if !strings.Contains(path, "?") {
+		return []string{path}
+	}
+
+	paths := []string{""}
+	for _, seg := range strings.Split(path[1:], "/") {
+		optional := len(seg) > 1 && seg[len(seg)-1] == '?'
+		seg = strings.TrimSuffix(seg, "?")
+		size := len(paths)
+		for i := 0; i < size; i++ {
+			if optional {
+				paths = append(paths, paths[i])
+			}
+			paths[i] += "/" + seg
+		}
+	}
+
+	for i, p := range paths {
+		if p == "" {
+			paths[i] = "/"
+		}
 	}
-	currentNode.handler = handleFunc
+	return paths
 }
 
-func (n *node) childOrCreate(seg string) *node {
-	// go to wildCardChild
-	if seg == "*" {
+// lookupChild
+// find the child of n for seg without creating it,
+// conflict is the existing child which can not exist with seg
+func (n *node) lookupChild(seg string) (child *node, conflict *node) {
+	// wildCardChild
+	if seg[0] == '*' {
 		if n.paramChild != nil {
-			panic(fmt.Sprintf(
-				`paramChild and wildCardChild can not exist at the same time: paramChild :%s already exist!`,
-				n.paramChild.path))
+			return nil, n.paramChild
 		}
 		if n.regChild != nil {
-			panic(fmt.Sprintf(
-				`regChild and wildCardChild can not exist at the same time: regChild %s already exist!`,
-				n.regChild.path))
+			return nil, n.regChild
 		}
-		if n.wildCardChild == nil {
-			n.wildCardChild = &node{path: seg}
+		// "*" and "*name" or "*a" and "*b"
+		if n.wildCardChild != nil && n.wildCardChild.path != seg {
+			return nil, n.wildCardChild
 		}
-		return n.wildCardChild
+		return n.wildCardChild, nil
+	}
+
+	// patternChild
+	if isPatternSegment(seg) {
+		return n.lookupPatternChild(seg)
 	}
 
-	// go to paramChild and regChild
+	// paramChild and regChild
 	if seg[0] == ':' {
 		if n.wildCardChild != nil {
-			panic(fmt.Sprintf(
-				`wildCardChild and paramChild can not exist at the same time: wildCardChild %s already exist!`,
-				n.wildCardChild.path))
+			return nil, n.wildCardChild
 		}
 
-		// go to regChild
-		if seg[len(seg)-1] == ')' {
+		// regChild
+		if strings.ContainsAny(seg, "()") {
 			if n.paramChild != nil {
-				panic(fmt.Sprintf(
-					`paramChild and regChild can not exist at the same time: paramChild %s already exist!`,
-					n.paramChild.path))
+				return nil, n.paramChild
 			}
-
-			if n.regChild == nil {
-				// get "(.*)" from ":username(.*)"
-				param := strings.SplitN(seg, "(", 2)[0][1:]
-				n.regChild = &node{path: seg, pathParam: param}
+			if n.regChild != nil && n.regChild.path != seg {
+				return nil, n.regChild
 			}
-			return n.regChild
+			return n.regChild, nil
 		}
 
-		// go to paramChild
+		// paramChild
 		if n.regChild != nil {
-			panic(fmt.Sprintf(
-				`regChild and wildCardChild can not exist at the same time: regChild %s already exist!`,
-				n.regChild.path))
+			return nil, n.regChild
 		}
-
-		if n.paramChild == nil {
-			n.paramChild = &node{path: seg, pathParam: seg[1:]}
+		// ":id" and ":identifier", the value is captured by one name only
+		if n.paramChild != nil && n.paramChild.path != seg {
+			return nil, n.paramChild
 		}
-		return n.paramChild
+		return n.paramChild, nil
 	}
 
-	// go to child
-	// init node.child
-	if n.children == nil {
-		n.children = map[string]*node{}
+	return n.staticChild(seg), nil
+}
+
+// childOrCreate
+// seg is already checked by checkRoute
+// radix, hold the static children in a radix tree, see newRadixRouter
+func (n *node) childOrCreate(seg string, radix bool) *node {
+	// a new route goes through or ends inside a compressed node
+	if child := n.staticChild(seg); child != nil && child.path != seg {
+		n.setStaticChild(seg, child.split(len(seg)), radix)
 	}
 
-	child, ok := n.children[seg]
-	if !ok {
-		child = &node{
-			path: seg,
-		}
-		n.children[seg] = child
+	if child, _ := n.lookupChild(seg); child != nil {
+		return child
+	}
+
+	// go to wildCardChild
+	// "*" matches one segment, or all the rest ones if it is the last
+	// "*name" is a catch-all, captures all the rest segments into "name"
+	if seg[0] == '*' {
+		n.wildCardChild = &node{path: seg, pathParam: seg[1:]}
+		return n.wildCardChild
+	}
+
+	// go to patternChild
+	if isPatternSegment(seg) {
+		return n.createPatternChild(seg)
+	}
+
+	// go to regChild
+	if seg[0] == ':' && strings.ContainsAny(seg, "()") {
+		param, expr, _ := parseRegexSegment(seg)
+		n.regChild = &node{path: seg, pathParam: param, regExpr: expr}
+		return n.regChild
+	}
+
+	// go to paramChild
+	if seg[0] == ':' {
+		n.paramChild = &node{path: seg, pathParam: seg[1:]}
+		return n.paramChild
+	}
+
+	// go to child
+	child := &node{
+		path: seg,
 	}
+	n.setStaticChild(seg, child, radix)
 	return child
 }
 
 type matchInfo struct {
 	n          *node
-	pathParams map[string]string
+	pathParams Params
+	// the handler of the route chosen on n, see node.handlerFor
+	handler HandleFunc
 }
 
-func (r *router) findRoute(method string, path string) (*matchInfo, bool) {
-	currentNode, ok := r.trees[method]
+// findRoute
+// find the route of path ignoring the matchers of the routes,
+// see findRequestRoute
+func (r *router) findRoute(method string, path string, params Params) (matchInfo, bool) {
+	return r.findRequestRoute(nil, method, path, params)
+}
+
+// findRequestRoute
+// params is appended with the path params in the request path and returned
+// in matchInfo, so the caller can pass a reused slice to avoid allocation
+//
+// the children of a node are tried by precedence, from high to low:
+// 1. static child, /user/home
+// 2. pattern child, /user/:name.:ext, see matchPattern
+// 3. regex child, /user/:id(^[0-9]+$)
+// 4. param child, /user/:id
+// 5. wild card child matching one segment, /user/*/home
+// 6. wild card child with handler matching all the rest segments, /user/*
+// or catch-all child capturing all the rest segments, /files/*filepath
+// once a child can not lead to a node with handler for the rest segments,
+// the next one is tried (backtracking), so the most specific complete route wins.
+// pattern, regex, param and wild card children never match an empty segment.
+// regex, param and wild card children exclude each other at the same node, see lookupChild,
+// while static and pattern children can exist with any of them,
+// so backtracking goes through static child, the pattern children in order,
+// then the one of regex, param or wild card child
+//
+// the matchers of the routes on a node are evaluated against req once the node
+// is reached by path, a node without any route matching req is backtracked
+// like a node without handler. req is nil to ignore the matchers
+func (r *router) findRequestRoute(req *http.Request, method string, path string, params Params) (matchInfo, bool) {
+	root, ok := r.trees[method]
 	if !ok {
-		return nil, false
+		return matchInfo{}, false
+	}
+
+	// such as "*" of "OPTIONS *"
+	if path == "" || path[0] != '/' {
+		return matchInfo{}, false
 	}
 
 	// root path
 	if path == "/" {
-		return &matchInfo{n: currentNode}, true
+		handler := root.handlerFor(req)
+		return matchInfo{n: root, pathParams: params, handler: handler}, handler != nil
 	}
 
 	// remove first "/"
-	path2Split := path[1:]
-	segs := strings.Split(path2Split, "/")
-	// used by paramChild node
-	var pathParams map[string]string
-	// used by tail wild card node, when regular match failed, try this
-	var tailWildCardNode *node
-	for _, seg := range segs {
-		// regular match
-		child, withParam, found := currentNode.childOf(seg)
-
-		// try to cache tail wild card node
-		wcNode, twcFound := currentNode.wildCardChildOf()
-		if twcFound {
-			tailWildCardNode = wcNode
-		}
-
-		// collect the path params in the request path
-		if withParam {
-			if pathParams == nil {
-				pathParams = make(map[string]string)
-			}
-			pathParams[child.pathParam] = seg
-		}
+	n, params, handler := root.match(path[1:], params, r.caseInsensitive, req)
+	if handler == nil {
+		return matchInfo{}, false
+	}
+	return matchInfo{n: n, pathParams: params, handler: handler}, true
+}
 
-		if !found {
-			// regular match failed, then return cached tail wild card node
-			if tailWildCardNode != nil {
-				return &matchInfo{n: tailWildCardNode, pathParams: pathParams}, true
-			}
-			return nil, false
+// allowedMethods
+// collect the methods which have a route registered for path,
+// regardless of the matchers of the routes
+// the result is unordered
+func (r *router) allowedMethods(path string) []string {
+	var allowed []string
+	for method := range r.trees {
+		if _, found := r.findRoute(method, path, nil); found {
+			allowed = append(allowed, method)
 		}
-
-		currentNode = child
 	}
-
-	return &matchInfo{n: currentNode, pathParams: pathParams}, true
+	return allowed
 }
 
-func (n *node) childOf(path string) (node *node, withParam bool, found bool) {
-	// 1. check children
-	// 2. check regChild
-	// 3. check paramChild
-	// at last: check wildCardChild
+// match
+// match path, the rest of the request path without leading '/',
+// against the children of n, see findRequestRoute for the precedence
+// fold, try the static children case-insensitively after the exact one
+// return the node of the route and its handler, nil handler if not found
+func (n *node) match(path string, params Params, fold bool, req *http.Request) (*node, Params, HandleFunc) {
+	seg, rest, last := cutSegment(path)
+
+	// 1. static child
+	if child := n.staticChild(seg); child != nil {
+		if res, ps, handler := child.matchStatic(path, params, fold, req); handler != nil {
+			return res, ps, handler
+		}
+	}
+	if fold {
+		if res, ps, handler := n.matchStaticFold(path, seg, params, req); handler != nil {
+			return res, ps, handler
+		}
+	}
 
-	if n.children == nil {
-		// How does children is nil?
-		// check regChild
-		if n.regChild != nil {
-			matchRule := "(" + strings.SplitN(n.regChild.path, "(", 2)[1]
-			matched, err := regexp.Match(matchRule, []byte(path))
-			if err != nil {
-				return nil, false, false
-			}
-			if matched {
-				return n.regChild, true, true
-			}
+	if seg == "" {
+		return nil, params, nil
+	}
+
+	// 2. pattern children, the more specific first
+	for _, child := range n.patternChildren {
+		ps, ok := child.matchPattern(seg, params)
+		if !ok {
+			continue
+		}
+		if res, ps, handler := child.matchRest(rest, last, ps, fold, req); handler != nil {
+			return res, ps, handler
 		}
+	}
 
-		// check paramChild
-		if n.paramChild != nil {
-			return n.paramChild, true, true
+	// 3. regex child, check against the request segment
+	// on failure the appended param is dropped by going on with params
+	if n.regChild != nil && n.regChild.regExpr.MatchString(seg) {
+		ps := append(params, Param{Key: n.regChild.pathParam, Value: seg})
+		if res, ps, handler := n.regChild.matchRest(rest, last, ps, fold, req); handler != nil {
+			return res, ps, handler
 		}
+	}
 
-		// at last: return wildCardChild
-		return n.wildCardChild, false, n.wildCardChild != nil
+	// 4. param child
+	if n.paramChild != nil {
+		ps := append(params, Param{Key: n.paramChild.pathParam, Value: seg})
+		if res, ps, handler := n.paramChild.matchRest(rest, last, ps, fold, req); handler != nil {
+			return res, ps, handler
+		}
 	}
 
-	child, ok := n.children[path]
-	if !ok {
-		// How does regular child not found
-		// check regChild
-		if n.regChild != nil {
-			matchRule := "(" + strings.SplitN(n.path, "(", 2)[1]
-			matched, err := regexp.Match(matchRule, []byte(n.path))
-			if err != nil {
-				return nil, false, false
-			}
-			if matched {
-				return n.regChild, true, true
-			}
+	if n.wildCardChild != nil {
+		// catch-all, captures all the rest segments, including '/'
+		if n.wildCardChild.pathParam != "" {
+			ps := append(params, Param{Key: n.wildCardChild.pathParam, Value: path})
+			return n.wildCardChild, ps, n.wildCardChild.handlerFor(req)
 		}
 
-		// check paramChild
-		if n.paramChild != nil {
-			return n.paramChild, true, true
+		// 5. wild card child matching one segment
+		if res, ps, handler := n.wildCardChild.matchRest(rest, last, params, fold, req); handler != nil {
+			return res, ps, handler
 		}
 
-		// at last: return wildCardChild
-		return n.wildCardChild, false, n.wildCardChild != nil
+		// 6. tail wild card matching all the rest segments
+		if handler := n.wildCardChild.handlerFor(req); handler != nil {
+			return n.wildCardChild, params, handler
+		}
 	}
 
-	// regular child found, return it
-	return child, false, ok
+	return nil, params, nil
 }
 
-func (n *node) wildCardChildOf() (node *node, found bool) {
-	if n.wildCardChild != nil {
-		if n.wildCardChild.handler != nil {
-			return n.wildCardChild, true
-		}
+// matchRest
+// n already matched a segment, match the rest segments against its children
+// a route is complete only when the last node has a route matching req
+func (n *node) matchRest(rest string, last bool, params Params, fold bool, req *http.Request) (*node, Params, HandleFunc) {
+	if last {
+		return n, params, n.handlerFor(req)
+	}
+	return n.match(rest, params, fold, req)
+}
+
+// matchStatic
+// n is the static child keyed by the first segment of path,
+// a compressed n matches all its segments at once, see compact
+func (n *node) matchStatic(path string, params Params, fold bool, req *http.Request) (*node, Params, HandleFunc) {
+	if len(path) < len(n.path) {
+		return nil, params, nil
+	}
+	if prefix := path[:len(n.path)]; prefix != n.path && !(fold && strings.EqualFold(prefix, n.path)) {
+		return nil, params, nil
+	}
+
+	if len(path) == len(n.path) {
+		return n, params, n.handlerFor(req)
+	}
+	if path[len(n.path)] != '/' {
+		return nil, params, nil
+	}
+	return n.match(path[len(n.path)+1:], params, fold, req)
+}
+
+// cutSegment
+// cut the first segment of path, which has no leading '/'
+// walk the segments one by one instead of "strings.Split", which allocates
+func cutSegment(path string) (seg string, rest string, last bool) {
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		return path[:i], path[i+1:], false
 	}
-	return n.wildCardChild, false
+	return path, "", true
 }
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -84,7 +88,7 @@ func TestRouter_addRoute(t *testing.T) {
 	}
 
 	testRouter := newRouter()
-	var fakeHandleFunc handleFunc = func(ctx *Context) {}
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
 	for _, tr := range testRoutes {
 		testRouter.addRoute(tr.method, tr.path, fakeHandleFunc)
 	}
@@ -392,7 +396,7 @@ func TestRouter_findRoute(t *testing.T) {
 	}
 
 	testRouter := newRouter()
-	var fakeHandleFunc handleFunc = func(ctx *Context) {}
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
 	for _, tr := range testRoutes {
 		testRouter.addRoute(tr.method, tr.path, fakeHandleFunc)
 	}
@@ -443,18 +447,9 @@ func TestRouter_findRoute(t *testing.T) {
 		},
 		{
 			name:   "no handler",
-			expect: true,
+			expect: false,
 			method: http.MethodPost,
 			path:   "/order",
-			info: &matchInfo{
-				n: &node{
-					path: "order",
-					wildCardChild: &node{
-						path:    "*",
-						handler: fakeHandleFunc,
-					},
-				},
-			},
 		},
 		{
 			name:   "depth two",
@@ -514,8 +509,8 @@ func TestRouter_findRoute(t *testing.T) {
 					path:    "detail",
 					handler: fakeHandleFunc,
 				},
-				pathParams: map[string]string{
-					"id": "2",
+				pathParams: Params{
+					{Key: "id", Value: "2"},
 				},
 			},
 		},
@@ -524,7 +519,7 @@ func TestRouter_findRoute(t *testing.T) {
 	// Test: find route
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			info, found := testRouter.findRoute(tc.method, tc.path)
+			info, found := testRouter.findRoute(tc.method, tc.path, nil)
 
 			// compare found result
 			assert.Equal(t, tc.expect, found)
@@ -541,3 +536,1163 @@ func TestRouter_findRoute(t *testing.T) {
 		})
 	}
 }
+
+// benchRoutes
+// routes used by the routing benchmarks
+var benchRoutes = []string{
+	"/",
+	"/user",
+	"/user/home",
+	"/user/:id",
+	"/user/:id/profile",
+	"/order/detail",
+	"/order/:id/items/:item",
+	"/api/v1/repos/detail",
+	"/api/v1/repos/:owner/:repo/issues",
+}
+
+func newBenchRouter() *router {
+	r := newRouter()
+	for _, path := range benchRoutes {
+		r.addRoute(http.MethodGet, path, func(ctx *Context) {})
+	}
+	return r
+}
+
+func BenchmarkRouter_findRoute(b *testing.B) {
+	r := newBenchRouter()
+	benchmarks := []struct {
+		name string
+		path string
+	}{
+		{name: "static", path: "/user/home"},
+		{name: "static deep", path: "/api/v1/repos/detail"},
+		{name: "param", path: "/user/123"},
+		{name: "params", path: "/api/v1/repos/golang/go/issues"},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			params := make(Params, 0, 8)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				info, _ := r.findRoute(http.MethodGet, bm.path, params[:0])
+				params = info.pathParams
+			}
+		})
+	}
+}
+
+func TestRouter_findRouteZeroAlloc(t *testing.T) {
+	r := newBenchRouter()
+	paths := []string{
+		"/user/home",
+		"/api/v1/repos/detail",
+		"/user/123",
+		"/api/v1/repos/golang/go/issues",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			params := make(Params, 0, 8)
+			allocs := testing.AllocsPerRun(100, func() {
+				info, found := r.findRoute(http.MethodGet, path, params[:0])
+				if !found {
+					t.Fatalf("%s not found", path)
+				}
+				params = info.pathParams
+			})
+			assert.Equal(t, float64(0), allocs)
+		})
+	}
+}
+
+func TestRouter_addRouteInvalidRegex(t *testing.T) {
+	r := newRouter()
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/user/:id([0-9]+", func(ctx *Context) {})
+	})
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/user/:id[0-9]+)", func(ctx *Context) {})
+	})
+}
+
+func BenchmarkNode_childOfRegex(b *testing.B) {
+	r := newRouter()
+	r.addRoute(http.MethodGet, "/user/:id(^[0-9]+$)", func(ctx *Context) {})
+	userNode := r.trees[http.MethodGet].children["user"]
+
+	// before: the regex was rebuilt and compiled on every match
+	b.Run("compile per match", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			matchRule := "(" + strings.SplitN(userNode.regChild.path, "(", 2)[1]
+			_, _ = regexp.Match(matchRule, []byte("12345"))
+		}
+	})
+
+	// after: the regex is compiled once at registration time
+	b.Run("precompiled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			userNode.match("12345", nil, false, nil)
+		}
+	})
+}
+
+func TestRouter_findRouteRegex(t *testing.T) {
+	testRoutes := []struct {
+		method string
+		path   string
+	}{
+		{
+			method: http.MethodGet,
+			path:   "/user/home",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/user/:id(^[0-9]+$)",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/user/:id(^[0-9]+$)/detail",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/order/:sn(^[a-z]+-[0-9]+$)",
+		},
+		{
+			method: http.MethodGet,
+			path:   "/t/:time(^[0-9]{2}:[0-9]{2}$)",
+		},
+	}
+
+	testRouter := newRouter()
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	for _, tr := range testRoutes {
+		testRouter.addRoute(tr.method, tr.path, fakeHandleFunc)
+	}
+
+	testCases := []struct {
+		name   string
+		expect bool
+		path   string
+		info   *matchInfo
+	}{
+		{
+			name:   "static sibling of regex",
+			expect: true,
+			path:   "/user/home",
+			info: &matchInfo{
+				n: &node{
+					path:    "home",
+					handler: fakeHandleFunc,
+				},
+			},
+		},
+		{
+			name:   "regex sibling of static",
+			expect: true,
+			path:   "/user/123",
+			info: &matchInfo{
+				n: &node{
+					path:      ":id(^[0-9]+$)",
+					pathParam: "id",
+					handler:   fakeHandleFunc,
+					children: map[string]*node{
+						"detail": {
+							path:    "detail",
+							handler: fakeHandleFunc,
+						},
+					},
+				},
+				pathParams: Params{
+					{Key: "id", Value: "123"},
+				},
+			},
+		},
+		{
+			name:   "regex sibling of static in middle",
+			expect: true,
+			path:   "/user/123/detail",
+			info: &matchInfo{
+				n: &node{
+					path:    "detail",
+					handler: fakeHandleFunc,
+				},
+				pathParams: Params{
+					{Key: "id", Value: "123"},
+				},
+			},
+		},
+		{
+			name:   "regex sibling of static rejects",
+			expect: false,
+			path:   "/user/tom",
+		},
+		{
+			name:   "regex rejects",
+			expect: false,
+			path:   "/order/123",
+		},
+		{
+			name:   "regex rejects partial match",
+			expect: false,
+			path:   "/order/abc-123x",
+		},
+		{
+			name:   "regex matches",
+			expect: true,
+			path:   "/order/abc-123",
+			info: &matchInfo{
+				n: &node{
+					path:      ":sn(^[a-z]+-[0-9]+$)",
+					pathParam: "sn",
+					handler:   fakeHandleFunc,
+				},
+				pathParams: Params{
+					{Key: "sn", Value: "abc-123"},
+				},
+			},
+		},
+		{
+			name:   "regex with ':'",
+			expect: true,
+			path:   "/t/12:30",
+			info: &matchInfo{
+				n: &node{
+					path:      ":time(^[0-9]{2}:[0-9]{2}$)",
+					pathParam: "time",
+					handler:   fakeHandleFunc,
+				},
+				pathParams: Params{
+					{Key: "time", Value: "12:30"},
+				},
+			},
+		},
+		{
+			name:   "regex with ':' rejects",
+			expect: false,
+			path:   "/t/1230",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, found := testRouter.findRoute(http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.expect, found)
+			if !found {
+				return
+			}
+
+			msg, equal := tc.info.n.equal(info.n)
+			assert.True(t, equal, msg)
+			assert.Equal(t, tc.info.pathParams, info.pathParams)
+		})
+	}
+}
+
+func TestRouter_findRouteBacktracking(t *testing.T) {
+	// the handler of a route reports its own path,
+	// so that the matched route can be told apart
+	routes := []string{
+		"/a/b/d",
+		"/a/:x/c",
+		"/g/b/d",
+		"/g/*/w",
+		"/g/*",
+		"/h/1/d",
+		"/h/:x(^[0-9]+$)/n",
+		"/b/c",
+		"/b/:x",
+		"/c/:x/:y/z",
+		"/c/:x/static/y",
+		"/d/:x(^[a-z]+$)",
+		"/d/:x(^[a-z]+$)/e",
+		"/e/*/f/g",
+		"/e/*/f",
+		"/f/s/*",
+		"/f/*",
+	}
+
+	testRouter := newRouter()
+	for _, route := range routes {
+		route := route
+		testRouter.addRoute(http.MethodGet, route, func(ctx *Context) {
+			_, _ = ctx.Resp.Write([]byte(route))
+		})
+	}
+
+	testCases := []struct {
+		name        string
+		path        string
+		expectRoute string
+		expectParam Params
+	}{
+		{
+			name:        "static wins over param",
+			path:        "/a/b/d",
+			expectRoute: "/a/b/d",
+		},
+		{
+			name:        "static dead end falls back to param",
+			path:        "/a/b/c",
+			expectRoute: "/a/:x/c",
+			expectParam: Params{{Key: "x", Value: "b"}},
+		},
+		{
+			name:        "static dead end falls back to wild card",
+			path:        "/g/b/w",
+			expectRoute: "/g/*/w",
+		},
+		{
+			name:        "all dead end falls back to tail wild card",
+			path:        "/g/b/x/y",
+			expectRoute: "/g/*",
+		},
+		{
+			name:        "static wins over regex",
+			path:        "/h/1/d",
+			expectRoute: "/h/1/d",
+		},
+		{
+			name:        "static dead end falls back to regex",
+			path:        "/h/1/n",
+			expectRoute: "/h/:x(^[0-9]+$)/n",
+			expectParam: Params{{Key: "x", Value: "1"}},
+		},
+		{
+			name:        "regex rejects after static dead end",
+			path:        "/h/x/n",
+			expectRoute: "",
+		},
+		{
+			name:        "param",
+			path:        "/b/x",
+			expectRoute: "/b/:x",
+			expectParam: Params{{Key: "x", Value: "x"}},
+		},
+		{
+			name:        "static without handler",
+			path:        "/b",
+			expectRoute: "",
+		},
+		{
+			name:        "params restored on backtracking",
+			path:        "/c/1/static/y",
+			expectRoute: "/c/:x/static/y",
+			expectParam: Params{{Key: "x", Value: "1"}},
+		},
+		{
+			name:        "params of both segments",
+			path:        "/c/1/2/z",
+			expectRoute: "/c/:x/:y/z",
+			expectParam: Params{{Key: "x", Value: "1"}, {Key: "y", Value: "2"}},
+		},
+		{
+			name:        "regex rejects",
+			path:        "/d/123",
+			expectRoute: "",
+		},
+		{
+			name:        "regex with child",
+			path:        "/d/abc/e",
+			expectRoute: "/d/:x(^[a-z]+$)/e",
+			expectParam: Params{{Key: "x", Value: "abc"}},
+		},
+		{
+			name:        "wild card in middle, deeper route",
+			path:        "/e/1/f/g",
+			expectRoute: "/e/*/f/g",
+		},
+		{
+			name:        "wild card in middle",
+			path:        "/e/1/f",
+			expectRoute: "/e/*/f",
+		},
+		{
+			name:        "wild card in middle without tail",
+			path:        "/e/1/f/h",
+			expectRoute: "",
+		},
+		{
+			name:        "deeper tail wild card wins",
+			path:        "/f/s/1/2",
+			expectRoute: "/f/s/*",
+		},
+		{
+			name:        "shallower tail wild card",
+			path:        "/f/t/1/2",
+			expectRoute: "/f/*",
+		},
+		{
+			name:        "empty segment is not matched by param",
+			path:        "/b/",
+			expectRoute: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, found := testRouter.findRoute(http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.expectRoute != "", found)
+			if !found {
+				return
+			}
+
+			recorder := httptest.NewRecorder()
+			info.n.handler(&Context{Resp: recorder})
+			assert.Equal(t, tc.expectRoute, recorder.Body.String())
+			assert.Equal(t, tc.expectParam, info.pathParams)
+		})
+	}
+}
+
+func TestRouter_catchAll(t *testing.T) {
+	testRouter := newRouter()
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	testRouter.addRoute(http.MethodGet, "/files/*filepath", fakeHandleFunc)
+	testRouter.addRoute(http.MethodGet, "/files/static/logo", fakeHandleFunc)
+	testRouter.addRoute(http.MethodGet, "/user/:id/files/*filepath", fakeHandleFunc)
+	testRouter.addRoute(http.MethodGet, "/*path", fakeHandleFunc)
+
+	testCases := []struct {
+		name         string
+		path         string
+		expect       bool
+		expectNode   string
+		expectParams Params
+	}{
+		{
+			name:         "one segment",
+			path:         "/files/a.txt",
+			expect:       true,
+			expectNode:   "*filepath",
+			expectParams: Params{{Key: "filepath", Value: "a.txt"}},
+		},
+		{
+			name:         "all the rest segments",
+			path:         "/files/css/theme/main.css",
+			expect:       true,
+			expectNode:   "*filepath",
+			expectParams: Params{{Key: "filepath", Value: "css/theme/main.css"}},
+		},
+		{
+			name:       "static wins over catch-all",
+			path:       "/files/static/logo",
+			expect:     true,
+			expectNode: "logo",
+		},
+		{
+			name:         "static dead end falls back to catch-all",
+			path:         "/files/static/logo/big",
+			expect:       true,
+			expectNode:   "*filepath",
+			expectParams: Params{{Key: "filepath", Value: "static/logo/big"}},
+		},
+		{
+			name:       "catch-all after param",
+			path:       "/user/1/files/a/b",
+			expect:     true,
+			expectNode: "*filepath",
+			expectParams: Params{
+				{Key: "id", Value: "1"},
+				{Key: "filepath", Value: "a/b"},
+			},
+		},
+		{
+			name:         "root catch-all",
+			path:         "/user/1/photos",
+			expect:       true,
+			expectNode:   "*path",
+			expectParams: Params{{Key: "path", Value: "user/1/photos"}},
+		},
+		{
+			name:         "trailing slash is kept",
+			path:         "/files/css/",
+			expect:       true,
+			expectNode:   "*filepath",
+			expectParams: Params{{Key: "filepath", Value: "css/"}},
+		},
+		{
+			name:   "empty rest is not matched",
+			path:   "/files/",
+			expect: true,
+			// falls back to the root catch-all
+			expectNode:   "*path",
+			expectParams: Params{{Key: "path", Value: "files/"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, found := testRouter.findRoute(http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.expect, found)
+			if !found {
+				return
+			}
+			assert.Equal(t, tc.expectNode, info.n.path)
+			assert.Equal(t, tc.expectParams, info.pathParams)
+		})
+	}
+
+	// TEST: catch-all must be the last segment
+	r := newRouter()
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/files/*filepath/detail", fakeHandleFunc)
+	})
+
+	// TEST: conflicting wild cards
+	r = newRouter()
+	r.addRoute(http.MethodGet, "/files/*filepath", fakeHandleFunc)
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/files/*name", fakeHandleFunc)
+	})
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/files/*", fakeHandleFunc)
+	})
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/files/:name", fakeHandleFunc)
+	})
+
+	r = newRouter()
+	r.addRoute(http.MethodGet, "/files/*/detail", fakeHandleFunc)
+	assert.Panics(t, func() {
+		r.addRoute(http.MethodGet, "/files/*filepath", fakeHandleFunc)
+	})
+}
+
+func TestExpandOptional(t *testing.T) {
+	testCases := []struct {
+		name   string
+		path   string
+		expect []string
+	}{
+		{
+			name:   "no optional",
+			path:   "/reports/:year",
+			expect: []string{"/reports/:year"},
+		},
+		{
+			name:   "optional trailing param",
+			path:   "/reports/:year?",
+			expect: []string{"/reports/:year", "/reports"},
+		},
+		{
+			name:   "two optional segments",
+			path:   "/reports/:year?/:month?",
+			expect: []string{"/reports/:year/:month", "/reports/:month", "/reports/:year", "/reports"},
+		},
+		{
+			name:   "optional in middle",
+			path:   "/docs/latest?/intro",
+			expect: []string{"/docs/latest/intro", "/docs/intro"},
+		},
+		{
+			name:   "optional regex",
+			path:   "/user/:id(^[0-9]+$)?",
+			expect: []string{"/user/:id(^[0-9]+$)", "/user"},
+		},
+		{
+			name:   "question mark inside regex",
+			path:   "/user/:id(^[0-9]?$)",
+			expect: []string{"/user/:id(^[0-9]?$)"},
+		},
+		{
+			name:   "optional root segment",
+			path:   "/:lang?",
+			expect: []string{"/:lang", "/"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, expandOptional(tc.path))
+		})
+	}
+}
+
+func TestRouter_findRouteOptional(t *testing.T) {
+	testRouter := newRouter()
+	testRouter.addRoute(http.MethodGet, "/reports/:year?", func(ctx *Context) {
+		_, _ = ctx.Resp.Write([]byte("reports"))
+	})
+	testRouter.addRoute(http.MethodGet, "/files/*filepath?", func(ctx *Context) {})
+
+	testCases := []struct {
+		name         string
+		path         string
+		expect       bool
+		expectParams Params
+	}{
+		{
+			name:   "absent",
+			path:   "/reports",
+			expect: true,
+		},
+		{
+			name:         "present",
+			path:         "/reports/2022",
+			expect:       true,
+			expectParams: Params{{Key: "year", Value: "2022"}},
+		},
+		{
+			name:   "catch-all absent",
+			path:   "/files",
+			expect: true,
+		},
+		{
+			name:         "catch-all present",
+			path:         "/files/a/b",
+			expect:       true,
+			expectParams: Params{{Key: "filepath", Value: "a/b"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, found := testRouter.findRoute(http.MethodGet, tc.path, nil)
+			assert.Equal(t, tc.expect, found)
+			assert.Equal(t, tc.expectParams, info.pathParams)
+		})
+	}
+
+	// TEST: both expanded routes share the handler
+	absent, _ := testRouter.findRoute(http.MethodGet, "/reports", nil)
+	present, _ := testRouter.findRoute(http.MethodGet, "/reports/2022", nil)
+	assert.Equal(t, reflect.ValueOf(absent.n.handler).Pointer(), reflect.ValueOf(present.n.handler).Pointer())
+
+	// TEST: conflict with the expanded route
+	assert.Panics(t, func() {
+		testRouter.addRoute(http.MethodGet, "/reports", func(ctx *Context) {})
+	})
+}
+
+// githubAPI
+// the routes of GitHub REST API v3, a common routing benchmark set
+var githubAPI = []struct {
+	method string
+	path   string
+}{
+	{http.MethodGet, "/authorizations"},
+	{http.MethodGet, "/authorizations/:id"},
+	{http.MethodPost, "/authorizations"},
+	{http.MethodDelete, "/authorizations/:id"},
+	{http.MethodGet, "/applications/:client_id/tokens/:access_token"},
+	{http.MethodDelete, "/applications/:client_id/tokens"},
+	{http.MethodDelete, "/applications/:client_id/tokens/:access_token"},
+	{http.MethodGet, "/events"},
+	{http.MethodGet, "/repos/:owner/:repo/events"},
+	{http.MethodGet, "/networks/:owner/:repo/events"},
+	{http.MethodGet, "/orgs/:org/events"},
+	{http.MethodGet, "/users/:user/received_events"},
+	{http.MethodGet, "/users/:user/received_events/public"},
+	{http.MethodGet, "/users/:user/events"},
+	{http.MethodGet, "/users/:user/events/public"},
+	{http.MethodGet, "/users/:user/events/orgs/:org"},
+	{http.MethodGet, "/feeds"},
+	{http.MethodGet, "/notifications"},
+	{http.MethodGet, "/repos/:owner/:repo/notifications"},
+	{http.MethodPut, "/notifications"},
+	{http.MethodPut, "/repos/:owner/:repo/notifications"},
+	{http.MethodGet, "/notifications/threads/:id"},
+	{http.MethodGet, "/notifications/threads/:id/subscription"},
+	{http.MethodPut, "/notifications/threads/:id/subscription"},
+	{http.MethodDelete, "/notifications/threads/:id/subscription"},
+	{http.MethodGet, "/repos/:owner/:repo/stargazers"},
+	{http.MethodGet, "/users/:user/starred"},
+	{http.MethodGet, "/user/starred"},
+	{http.MethodGet, "/user/starred/:owner/:repo"},
+	{http.MethodPut, "/user/starred/:owner/:repo"},
+	{http.MethodDelete, "/user/starred/:owner/:repo"},
+	{http.MethodGet, "/repos/:owner/:repo/subscribers"},
+	{http.MethodGet, "/users/:user/subscriptions"},
+	{http.MethodGet, "/user/subscriptions"},
+	{http.MethodGet, "/repos/:owner/:repo/subscription"},
+	{http.MethodPut, "/repos/:owner/:repo/subscription"},
+	{http.MethodDelete, "/repos/:owner/:repo/subscription"},
+	{http.MethodGet, "/user/subscriptions/:owner/:repo"},
+	{http.MethodPut, "/user/subscriptions/:owner/:repo"},
+	{http.MethodDelete, "/user/subscriptions/:owner/:repo"},
+	{http.MethodGet, "/users/:user/gists"},
+	{http.MethodGet, "/gists"},
+	{http.MethodGet, "/gists/:id"},
+	{http.MethodPost, "/gists"},
+	{http.MethodPut, "/gists/:id/star"},
+	{http.MethodDelete, "/gists/:id/star"},
+	{http.MethodGet, "/gists/:id/star"},
+	{http.MethodPost, "/gists/:id/forks"},
+	{http.MethodDelete, "/gists/:id"},
+	{http.MethodGet, "/repos/:owner/:repo/git/blobs/:sha"},
+	{http.MethodPost, "/repos/:owner/:repo/git/blobs"},
+	{http.MethodGet, "/repos/:owner/:repo/git/commits/:sha"},
+	{http.MethodPost, "/repos/:owner/:repo/git/commits"},
+	{http.MethodGet, "/repos/:owner/:repo/git/refs"},
+	{http.MethodPost, "/repos/:owner/:repo/git/refs"},
+	{http.MethodGet, "/repos/:owner/:repo/git/tags/:sha"},
+	{http.MethodPost, "/repos/:owner/:repo/git/tags"},
+	{http.MethodGet, "/repos/:owner/:repo/git/trees/:sha"},
+	{http.MethodPost, "/repos/:owner/:repo/git/trees"},
+	{http.MethodGet, "/issues"},
+	{http.MethodGet, "/user/issues"},
+	{http.MethodGet, "/orgs/:org/issues"},
+	{http.MethodGet, "/repos/:owner/:repo/issues"},
+	{http.MethodGet, "/repos/:owner/:repo/issues/:number"},
+	{http.MethodPost, "/repos/:owner/:repo/issues"},
+	{http.MethodGet, "/repos/:owner/:repo/assignees"},
+	{http.MethodGet, "/repos/:owner/:repo/assignees/:assignee"},
+	{http.MethodGet, "/repos/:owner/:repo/issues/:number/comments"},
+	{http.MethodPost, "/repos/:owner/:repo/issues/:number/comments"},
+	{http.MethodGet, "/repos/:owner/:repo/issues/:number/events"},
+	{http.MethodGet, "/repos/:owner/:repo/labels"},
+	{http.MethodGet, "/repos/:owner/:repo/labels/:name"},
+	{http.MethodPost, "/repos/:owner/:repo/labels"},
+	{http.MethodDelete, "/repos/:owner/:repo/labels/:name"},
+	{http.MethodGet, "/repos/:owner/:repo/issues/:number/labels"},
+	{http.MethodPost, "/repos/:owner/:repo/issues/:number/labels"},
+	{http.MethodDelete, "/repos/:owner/:repo/issues/:number/labels/:name"},
+	{http.MethodPut, "/repos/:owner/:repo/issues/:number/labels"},
+	{http.MethodDelete, "/repos/:owner/:repo/issues/:number/labels"},
+	{http.MethodGet, "/repos/:owner/:repo/milestones/:number/labels"},
+	{http.MethodGet, "/repos/:owner/:repo/milestones"},
+	{http.MethodGet, "/repos/:owner/:repo/milestones/:number"},
+	{http.MethodPost, "/repos/:owner/:repo/milestones"},
+	{http.MethodDelete, "/repos/:owner/:repo/milestones/:number"},
+	{http.MethodGet, "/emojis"},
+	{http.MethodGet, "/gitignore/templates"},
+	{http.MethodGet, "/gitignore/templates/:name"},
+	{http.MethodPost, "/markdown"},
+	{http.MethodPost, "/markdown/raw"},
+	{http.MethodGet, "/meta"},
+	{http.MethodGet, "/rate_limit"},
+	{http.MethodGet, "/users/:user/orgs"},
+	{http.MethodGet, "/user/orgs"},
+	{http.MethodGet, "/orgs/:org"},
+	{http.MethodGet, "/orgs/:org/members"},
+	{http.MethodGet, "/orgs/:org/members/:user"},
+	{http.MethodDelete, "/orgs/:org/members/:user"},
+	{http.MethodGet, "/orgs/:org/public_members"},
+	{http.MethodGet, "/orgs/:org/public_members/:user"},
+	{http.MethodPut, "/orgs/:org/public_members/:user"},
+	{http.MethodDelete, "/orgs/:org/public_members/:user"},
+	{http.MethodGet, "/orgs/:org/teams"},
+	{http.MethodGet, "/teams/:id"},
+	{http.MethodPost, "/orgs/:org/teams"},
+	{http.MethodDelete, "/teams/:id"},
+	{http.MethodGet, "/teams/:id/members"},
+	{http.MethodGet, "/teams/:id/members/:user"},
+	{http.MethodPut, "/teams/:id/members/:user"},
+	{http.MethodDelete, "/teams/:id/members/:user"},
+	{http.MethodGet, "/teams/:id/repos"},
+	{http.MethodGet, "/teams/:id/repos/:owner/:repo"},
+	{http.MethodPut, "/teams/:id/repos/:owner/:repo"},
+	{http.MethodDelete, "/teams/:id/repos/:owner/:repo"},
+	{http.MethodGet, "/user/teams"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls/:number"},
+	{http.MethodPost, "/repos/:owner/:repo/pulls"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls/:number/commits"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls/:number/files"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls/:number/merge"},
+	{http.MethodPut, "/repos/:owner/:repo/pulls/:number/merge"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls/:number/comments"},
+	{http.MethodPut, "/repos/:owner/:repo/pulls/:number/comments"},
+	{http.MethodGet, "/user/repos"},
+	{http.MethodGet, "/users/:user/repos"},
+	{http.MethodGet, "/orgs/:org/repos"},
+	{http.MethodGet, "/repositories"},
+	{http.MethodPost, "/user/repos"},
+	{http.MethodPost, "/orgs/:org/repos"},
+	{http.MethodGet, "/repos/:owner/:repo"},
+	{http.MethodDelete, "/repos/:owner/:repo"},
+	{http.MethodGet, "/repos/:owner/:repo/contributors"},
+	{http.MethodGet, "/repos/:owner/:repo/languages"},
+	{http.MethodGet, "/repos/:owner/:repo/teams"},
+	{http.MethodGet, "/repos/:owner/:repo/tags"},
+	{http.MethodGet, "/repos/:owner/:repo/branches"},
+	{http.MethodGet, "/repos/:owner/:repo/branches/:branch"},
+	{http.MethodGet, "/repos/:owner/:repo/collaborators"},
+	{http.MethodGet, "/repos/:owner/:repo/collaborators/:user"},
+	{http.MethodPut, "/repos/:owner/:repo/collaborators/:user"},
+	{http.MethodDelete, "/repos/:owner/:repo/collaborators/:user"},
+	{http.MethodGet, "/repos/:owner/:repo/comments"},
+	{http.MethodGet, "/repos/:owner/:repo/commits/:sha/comments"},
+	{http.MethodPost, "/repos/:owner/:repo/commits/:sha/comments"},
+	{http.MethodGet, "/repos/:owner/:repo/comments/:id"},
+	{http.MethodDelete, "/repos/:owner/:repo/comments/:id"},
+	{http.MethodGet, "/repos/:owner/:repo/commits"},
+	{http.MethodGet, "/repos/:owner/:repo/commits/:sha"},
+	{http.MethodGet, "/repos/:owner/:repo/readme"},
+	{http.MethodGet, "/repos/:owner/:repo/keys"},
+	{http.MethodGet, "/repos/:owner/:repo/keys/:id"},
+	{http.MethodPost, "/repos/:owner/:repo/keys"},
+	{http.MethodDelete, "/repos/:owner/:repo/keys/:id"},
+	{http.MethodGet, "/repos/:owner/:repo/downloads"},
+	{http.MethodGet, "/repos/:owner/:repo/downloads/:id"},
+	{http.MethodDelete, "/repos/:owner/:repo/downloads/:id"},
+	{http.MethodGet, "/repos/:owner/:repo/forks"},
+	{http.MethodPost, "/repos/:owner/:repo/forks"},
+	{http.MethodGet, "/repos/:owner/:repo/hooks"},
+	{http.MethodGet, "/repos/:owner/:repo/hooks/:id"},
+	{http.MethodPost, "/repos/:owner/:repo/hooks"},
+	{http.MethodPost, "/repos/:owner/:repo/hooks/:id/tests"},
+	{http.MethodDelete, "/repos/:owner/:repo/hooks/:id"},
+	{http.MethodPost, "/repos/:owner/:repo/merges"},
+	{http.MethodGet, "/repos/:owner/:repo/releases"},
+	{http.MethodGet, "/repos/:owner/:repo/releases/:id"},
+	{http.MethodPost, "/repos/:owner/:repo/releases"},
+	{http.MethodDelete, "/repos/:owner/:repo/releases/:id"},
+	{http.MethodGet, "/repos/:owner/:repo/releases/:id/assets"},
+	{http.MethodGet, "/repos/:owner/:repo/stats/contributors"},
+	{http.MethodGet, "/repos/:owner/:repo/stats/commit_activity"},
+	{http.MethodGet, "/repos/:owner/:repo/stats/code_frequency"},
+	{http.MethodGet, "/repos/:owner/:repo/stats/participation"},
+	{http.MethodGet, "/repos/:owner/:repo/stats/punch_card"},
+	{http.MethodGet, "/repos/:owner/:repo/statuses/:ref"},
+	{http.MethodPost, "/repos/:owner/:repo/statuses/:ref"},
+	{http.MethodGet, "/search/repositories"},
+	{http.MethodGet, "/search/code"},
+	{http.MethodGet, "/search/issues"},
+	{http.MethodGet, "/search/users"},
+	{http.MethodGet, "/legacy/issues/search/:owner/:repository/:state/:keyword"},
+	{http.MethodGet, "/legacy/repos/search/:keyword"},
+	{http.MethodGet, "/legacy/user/search/:keyword"},
+	{http.MethodGet, "/legacy/user/email/:email"},
+	{http.MethodGet, "/users/:user"},
+	{http.MethodGet, "/user"},
+	{http.MethodGet, "/users"},
+	{http.MethodGet, "/user/emails"},
+	{http.MethodPost, "/user/emails"},
+	{http.MethodDelete, "/user/emails"},
+	{http.MethodGet, "/users/:user/followers"},
+	{http.MethodGet, "/user/followers"},
+	{http.MethodGet, "/users/:user/following"},
+	{http.MethodGet, "/user/following"},
+	{http.MethodGet, "/user/following/:user"},
+	{http.MethodGet, "/users/:user/following/:target_user"},
+	{http.MethodPut, "/user/following/:user"},
+	{http.MethodDelete, "/user/following/:user"},
+	{http.MethodGet, "/users/:user/keys"},
+	{http.MethodGet, "/user/keys"},
+	{http.MethodGet, "/user/keys/:id"},
+	{http.MethodPost, "/user/keys"},
+	{http.MethodDelete, "/user/keys/:id"},
+}
+
+// apiRoutes
+// a 500 routes API, 50 resources with 10 routes each under "/api/v1"
+func apiRoutes() []string {
+	var paths []string
+	for i := 0; i < 50; i++ {
+		res := fmt.Sprintf("/api/v1/resource%02d", i)
+		paths = append(paths,
+			res,
+			res+"/search",
+			res+"/export/csv",
+			res+"/:id",
+			res+"/:id/history",
+			res+"/:id/comments",
+			res+"/:id/comments/:comment",
+			res+"/:id/attachments",
+			res+"/:id/attachments/:attachment/download",
+			res+"/:id/owner/settings",
+		)
+	}
+	return paths
+}
+
+// requestPath
+// fill the params of route with values, such as "/user/:id" into "/user/id1"
+func requestPath(route string) string {
+	segs := strings.Split(route, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") {
+			segs[i] = seg[1:] + "1"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func TestRadixRouter_findRoute(t *testing.T) {
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	testRoutes := []struct {
+		method string
+		path   string
+	}{
+		// split a compressed node in the middle and at its end
+		{http.MethodGet, "/a/b/c/d"},
+		{http.MethodGet, "/a/b"},
+		{http.MethodGet, "/a/b/c/d/e/f"},
+		{http.MethodGet, "/a/b/x/y"},
+		{http.MethodGet, "/a/:id/c/d"},
+		{http.MethodGet, "/static/*filepath"},
+		{http.MethodGet, "/static/css/site.css"},
+		{http.MethodGet, "/files/:name.:ext"},
+		{http.MethodGet, "/files/readme.md"},
+		{http.MethodGet, "/reg/:id(^[0-9]+$)/detail/info"},
+		{http.MethodGet, "/reports/:year/:month?"},
+		{http.MethodGet, "/any/*/tail"},
+		{http.MethodGet, "/Mixed/Case/Path"},
+	}
+	for _, route := range githubAPI {
+		testRoutes = append(testRoutes, route)
+	}
+	for _, path := range apiRoutes() {
+		testRoutes = append(testRoutes, struct {
+			method string
+			path   string
+		}{http.MethodGet, path})
+	}
+
+	tree, radix := newRouter(), newRadixRouter()
+	for _, route := range testRoutes {
+		tree.addRoute(route.method, route.path, fakeHandleFunc)
+		radix.addRoute(route.method, route.path, fakeHandleFunc)
+	}
+	assert.Equal(t, tree.Routes(), radix.Routes())
+
+	paths := []string{
+		"/", "/a", "/a/b/c", "/a/b/c/d/e", "/a/b/x", "/a/b/", "/a/bb/c/d", "/a/9/c/d",
+		"/static/css/site.css", "/static/css/app.css", "/static/css", "/files/readme.md", "/files/a.txt",
+		"/reg/12/detail/info", "/reg/ab/detail/info", "/reports/2024", "/reports/2024/05",
+		"/any/x/tail", "/any/x/y", "/mixed/case/path", "/MIXED/case/Path", "/user/Repos",
+		"/api/v1", "/api/v1/resource07/export", "/api/v1/resource07/EXPORT/csv", "/api/v2/resource07",
+	}
+	for _, route := range testRoutes {
+		paths = append(paths, requestPath(route.path))
+	}
+
+	for _, fold := range []bool{false, true} {
+		tree.caseInsensitive, radix.caseInsensitive = fold, fold
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+			for _, path := range paths {
+				expect, expectFound := tree.findRoute(method, path, nil)
+				info, found := radix.findRoute(method, path, nil)
+				name := fmt.Sprintf("fold=%v %s %s", fold, method, path)
+				assert.Equal(t, expectFound, found, name)
+				if expectFound && found {
+					assert.Equal(t, expect.n.route, info.n.route, name)
+					assert.Equal(t, expect.pathParams, info.pathParams, name)
+				}
+			}
+		}
+	}
+}
+
+func TestRadixRouter_compact(t *testing.T) {
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	r := newRadixRouter()
+	r.addRoute(http.MethodGet, "/api/v1/repos/:owner", fakeHandleFunc)
+	r.addRoute(http.MethodGet, "/api/v1/repos/search", fakeHandleFunc)
+	root := r.trees[http.MethodGet]
+	api := root.staticChild("api")
+	assert.Equal(t, "api/v1/repos", api.path)
+	assert.Equal(t, ":owner", api.paramChild.path)
+	assert.Equal(t, "search", api.staticChild("search").path)
+
+	// TEST: split by a route ending inside the compressed node
+	r.addRoute(http.MethodGet, "/api/v1", fakeHandleFunc)
+	api = root.staticChild("api")
+	assert.Equal(t, "api/v1", api.path)
+	assert.Equal(t, "/api/v1", api.route)
+	assert.Equal(t, "repos", api.staticChild("repos").path)
+
+	// TEST: split by a route going through the compressed node
+	r.addRoute(http.MethodGet, "/api/v2/users/list", fakeHandleFunc)
+	api = root.staticChild("api")
+	assert.Equal(t, "api", api.path)
+	assert.Equal(t, "v1", api.staticChild("v1").path)
+	assert.Equal(t, "v2/users/list", api.staticChild("v2").path)
+
+	// TEST: duplicate and conflict inside compressed nodes
+	_, err := r.tryAddRoute(http.MethodGet, "/api/v2/users/list", fakeHandleFunc)
+	assert.ErrorIs(t, err, ErrDuplicateRoute)
+	_, err = r.tryAddRoute(http.MethodGet, "/api/v2/users", fakeHandleFunc)
+	assert.NoError(t, err)
+	_, err = r.tryAddRoute(http.MethodGet, "/api/v1/repos/*", fakeHandleFunc)
+	assert.ErrorIs(t, err, ErrConflictingRoute)
+	assert.Equal(t, "v2/users", api.staticChild("v2").path)
+
+	// TEST: a param child is not compressed, a shorter param name is still checked
+	r.addRoute(http.MethodGet, "/reports/:year", fakeHandleFunc)
+	_, err = r.tryAddRoute(http.MethodGet, "/reports/:y", fakeHandleFunc)
+	assert.ErrorIs(t, err, ErrDuplicateRoute)
+	r.addRoute(http.MethodGet, "/r/:year/:a", fakeHandleFunc)
+	_, err = r.tryAddRoute(http.MethodGet, "/r/:y/*", fakeHandleFunc)
+	assert.ErrorIs(t, err, ErrConflictingRoute)
+	assert.Nil(t, root.staticChild("r").paramChild.wildCardChild)
+	assert.Equal(t, "list", api.staticChild("v2").staticChild("list").path)
+}
+
+func TestRadixEdge(t *testing.T) {
+	var fakeHandleFunc HandleFunc = func(ctx *Context) {}
+	r := newRadixRouter()
+	for _, path := range []string{"/users", "/user", "/uploads", "/about", "/Users"} {
+		r.addRoute(http.MethodGet, path, fakeHandleFunc)
+	}
+	root := r.trees[http.MethodGet]
+	assert.Nil(t, root.children)
+
+	// TEST: keys sharing a prefix share the edge, children indexed by first byte
+	edge := root.static
+	assert.Equal(t, "", edge.label)
+	assert.Equal(t, "Uau", edge.indices)
+	u := edge.children[2]
+	assert.Equal(t, "u", u.label)
+	assert.Equal(t, "ps", u.indices)
+	user := u.children[1]
+	assert.Equal(t, "ser", user.label)
+	assert.Equal(t, "user", user.node.path)
+	assert.Equal(t, "s", user.children[0].label)
+	assert.Equal(t, "users", user.children[0].node.path)
+
+	// TEST: lookup
+	for _, key := range []string{"users", "user", "uploads", "about", "Users"} {
+		assert.Equal(t, key, root.staticChild(key).path)
+	}
+	for _, key := range []string{"", "u", "use", "userss", "up", "x"} {
+		assert.Nil(t, root.staticChild(key), key)
+	}
+
+	// TEST: keys in order
+	var keys []string
+	root.eachStatic(func(child *node) bool {
+		keys = append(keys, child.path)
+		return false
+	})
+	assert.Equal(t, []string{"Users", "about", "uploads", "user", "users"}, keys)
+
+	// TEST: case-insensitive keys in order
+	keys = keys[:0]
+	root.static.eachFold("USERS", func(child *node) bool {
+		keys = append(keys, child.path)
+		return false
+	})
+	assert.Equal(t, []string{"Users", "users"}, keys)
+}
+
+func newGithubRouter(newRouter func() *router) *router {
+	r := newRouter()
+	for _, route := range githubAPI {
+		r.addRoute(route.method, route.path, func(ctx *Context) {})
+	}
+	return r
+}
+
+func newAPIRouter(newRouter func() *router) *router {
+	r := newRouter()
+	for _, path := range apiRoutes() {
+		r.addRoute(http.MethodGet, path, func(ctx *Context) {})
+	}
+	return r
+}
+
+// routerImpls
+// the segment tree and the compressed one, compared by the benchmarks
+var routerImpls = []struct {
+	name      string
+	newRouter func() *router
+}{
+	{name: "tree", newRouter: newRouter},
+	{name: "radix", newRouter: newRadixRouter},
+}
+
+func benchmarkFindRoute(b *testing.B, r *router, method string, paths []string) {
+	params := make(Params, 0, 8)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			info, _ := r.findRoute(method, path, params[:0])
+			params = info.pathParams
+		}
+	}
+}
+
+func BenchmarkRouter_github(b *testing.B) {
+	var all []string
+	for _, route := range githubAPI {
+		if route.method == http.MethodGet {
+			all = append(all, requestPath(route.path))
+		}
+	}
+	benchmarks := []struct {
+		name  string
+		paths []string
+	}{
+		{name: "static", paths: []string{"/user/repos"}},
+		{name: "param", paths: []string{"/repos/julienschmidt/httprouter/stargazers"}},
+		{name: "all", paths: all},
+	}
+
+	for _, impl := range routerImpls {
+		r := newGithubRouter(impl.newRouter)
+		for _, bm := range benchmarks {
+			b.Run(impl.name+"/"+bm.name, func(b *testing.B) {
+				benchmarkFindRoute(b, r, http.MethodGet, bm.paths)
+			})
+		}
+	}
+}
+
+func BenchmarkRouter_api(b *testing.B) {
+	var all []string
+	for _, path := range apiRoutes() {
+		all = append(all, requestPath(path))
+	}
+	benchmarks := []struct {
+		name  string
+		paths []string
+	}{
+		{name: "static", paths: []string{"/api/v1/resource42/export/csv"}},
+		{name: "param", paths: []string{"/api/v1/resource42/123/attachments/456/download"}},
+		{name: "all", paths: all},
+	}
+
+	for _, impl := range routerImpls {
+		r := newAPIRouter(impl.newRouter)
+		for _, bm := range benchmarks {
+			b.Run(impl.name+"/"+bm.name, func(b *testing.B) {
+				benchmarkFindRoute(b, r, http.MethodGet, bm.paths)
+			})
+		}
+	}
+}
+
+// BenchmarkRouter_build
+// B/op is the memory held by the trees, plus the garbage of registration
+func BenchmarkRouter_build(b *testing.B) {
+	for _, impl := range routerImpls {
+		b.Run(impl.name+"/github", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				newGithubRouter(impl.newRouter)
+			}
+			reportRetained(b, func() *router { return newGithubRouter(impl.newRouter) })
+		})
+		b.Run(impl.name+"/api", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				newAPIRouter(impl.newRouter)
+			}
+			reportRetained(b, func() *router { return newAPIRouter(impl.newRouter) })
+		})
+	}
+}
+
+// reportRetained
+// report the heap retained by the router built by build,
+// that is what stays alive after the registration garbage is collected
+func reportRetained(b *testing.B, build func() *router) {
+	b.StopTimer()
+	defer b.StartTimer()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	r := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(r)
+	b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc)), "retained-B")
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo
+// a registered route, see HTTPServer.Routes
+type RouteInfo struct {
+	// the host pattern, see HTTPServer.Host. empty for the routes of all hosts
+	Host   string `json:"host,omitempty"`
+	Method string `json:"method"`
+	// the full pattern, optional segments are expanded into separate routes
+	Path string `json:"path"`
+	// func name of the HandleFunc, such as "main.userHandler"
+	Handler string `json:"handler"`
+	// func names of the route level middlewares, including the group ones,
+	// from the outermost one. global middlewares are not included
+	Middlewares []string `json:"middlewares"`
+	// descriptions of the matchers, see HTTPServer.Match
+	Matchers []string `json:"matchers,omitempty"`
+}
+
+// Routes
+// list the registered routes by walking the trees, ordered by path then method
+func (r *router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	for method, root := range r.trees {
+		root.walk(func(n *node) {
+			for _, mr := range n.matched {
+				routes = append(routes, RouteInfo{
+					Method:      method,
+					Path:        mr.route,
+					Handler:     mr.rt.handlerName,
+					Middlewares: mr.rt.middlewares,
+					Matchers:    mr.rt.matchers,
+				})
+			}
+			if n.handler != nil {
+				routes = append(routes, RouteInfo{
+					Method:      method,
+					Path:        n.route,
+					Handler:     n.rt.handlerName,
+					Middlewares: n.rt.middlewares,
+				})
+			}
+		})
+	}
+
+	// keep the matched routes of a path in registration order
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Routes
+// list the routes of all hosts, the ones registered on the server directly first,
+// then by host, see router.Routes for the order of a host
+func (h *HTTPServer) Routes() []RouteInfo {
+	routes := h.router.Routes()
+	hosts := make([]*hostRouter, 0, len(h.exactHosts)+len(h.patternHosts))
+	for _, hr := range h.exactHosts {
+		hosts = append(hosts, hr)
+	}
+	hosts = append(hosts, h.patternHosts...)
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].pattern < hosts[j].pattern
+	})
+
+	for _, hr := range hosts {
+		for _, rt := range hr.Routes() {
+			rt.Host = hr.pattern
+			routes = append(routes, rt)
+		}
+	}
+	return routes
+}
+
+// walk
+// call fn with n and all its descendants which have routes
+func (n *node) walk(fn func(n *node)) {
+	if n == nil {
+		return
+	}
+	if n.hasRoute() {
+		fn(n)
+	}
+	n.eachStatic(func(child *node) bool {
+		child.walk(fn)
+		return false
+	})
+	for _, child := range n.patternChildren {
+		child.walk(fn)
+	}
+	n.regChild.walk(fn)
+	n.paramChild.walk(fn)
+	n.wildCardChild.walk(fn)
+}
+
+// PrintRoutes
+// print the registered routes as a table
+func (h *HTTPServer) PrintRoutes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER\tMIDDLEWARES")
+	for _, rt := range h.Routes() {
+		path := rt.Host + rt.Path
+		if len(rt.Matchers) > 0 {
+			path += " [" + strings.Join(rt.Matchers, ", ") + "]"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rt.Method, path, rt.Handler, strings.Join(rt.Middlewares, ", "))
+	}
+	_ = tw.Flush()
+}
+
+// RoutesHandler
+// debug endpoint responding the registered routes in JSON
+// for example:
+//
+//	server.Get("/debug/routes", server.RoutesHandler())
+func (h *HTTPServer) RoutesHandler() HandleFunc {
+	return func(ctx *Context) {
+		if err := ctx.RespJSON(http.StatusOK, h.Routes()); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+// funcName
+// name of a func value, such as "main.userHandler" or "main.main.func1"
+func funcName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+	return f.Name()
+}
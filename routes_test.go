@@ -0,0 +1,71 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routesTestHandler(ctx *Context) {}
+
+func routesTestMiddleware(next HandleFunc) HandleFunc {
+	return next
+}
+
+func TestHTTPServer_Routes(t *testing.T) {
+	s := NewHTTPServer()
+	s.Get("/", routesTestHandler)
+	s.Get("/user/:id", routesTestHandler, routesTestMiddleware)
+	s.Post("/user/:id", routesTestHandler)
+	s.Get("/reports/:year?", routesTestHandler)
+	s.Get("/files/:name.:ext", routesTestHandler)
+	s.Get("/static/*filepath", routesTestHandler)
+	s.Group("/api", Recovery(nil)).Delete("/order/:sn(^[0-9]+$)", routesTestHandler, routesTestMiddleware)
+
+	handler := "Ch01.routesTestHandler"
+	expect := []RouteInfo{
+		{Method: http.MethodGet, Path: "/", Handler: handler, Middlewares: []string{}},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/api/order/:sn(^[0-9]+$)",
+			Handler:     handler,
+			Middlewares: []string{"Ch01.Recovery.func1", "Ch01.routesTestMiddleware"},
+		},
+		{Method: http.MethodGet, Path: "/files/:name.:ext", Handler: handler, Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/reports", Handler: handler, Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/reports/:year", Handler: handler, Middlewares: []string{}},
+		{Method: http.MethodGet, Path: "/static/*filepath", Handler: handler, Middlewares: []string{}},
+		{
+			Method:      http.MethodGet,
+			Path:        "/user/:id",
+			Handler:     handler,
+			Middlewares: []string{"Ch01.routesTestMiddleware"},
+		},
+		{Method: http.MethodPost, Path: "/user/:id", Handler: handler, Middlewares: []string{}},
+	}
+	assert.Equal(t, expect, s.Routes())
+
+	// TEST: table
+	buffer := &bytes.Buffer{}
+	s.PrintRoutes(buffer)
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, len(expect)+1)
+	assert.Equal(t, []string{"METHOD", "PATH", "HANDLER", "MIDDLEWARES"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"GET", "/user/:id", handler, "Ch01.routesTestMiddleware"}, strings.Fields(lines[7]))
+
+	// TEST: debug endpoint
+	s.Get("/debug/routes", s.RoutesHandler())
+	req := httptest.NewRequest(http.MethodGet, "/debug/routes", nil)
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var routes []RouteInfo
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &routes))
+	assert.Len(t, routes, len(expect)+1)
+}
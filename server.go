@@ -1,11 +1,33 @@
 package web
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 )
 
-type handleFunc func(ctx *Context)
+// HandleFunc
+// business logic of a route
+type HandleFunc func(ctx *Context)
+
+// methods
+// all http methods a route can be registered on, used by Any
+var methods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
 
 // ensure HTTPServer implement Server
 var _ Server = &HTTPServer{}
@@ -16,74 +38,316 @@ type Server interface {
 	// Start a Server
 	Start(addr string) error
 
-	// AddRoute
+	// Serve
+	// serve on a listener created by caller
+	Serve(l net.Listener) error
+
+	// Handle
 	// register route logic here
 	// - method, http request method
 	// - path, http request path
 	// - handleFunc, business logic func
-	addRoute(method string, path string, handleFunc handleFunc)
+	// - mws, route level middlewares, composed around handleFunc once
+	// - return the registered route, which can be named for URLFor
+	Handle(method string, path string, handleFunc HandleFunc, mws ...Middleware) *Route
 }
 
 type HTTPServer struct {
 	*router
+
+	// NotFoundHandler
+	// called when no route matches the request, default responds 404 "NOT FOUND"
+	NotFoundHandler HandleFunc
+	// MethodNotAllowedHandler
+	// called when the path is registered with other methods only,
+	// the "Allow" header is already set before it is called,
+	// default responds 405 "METHOD NOT ALLOWED"
+	MethodNotAllowedHandler HandleFunc
+
+	// owned http server, configured by HTTPServerOption
+	server *http.Server
+	logger *log.Logger
+
+	errorHandler ErrorHandler
+	tplEngine    TemplateEngine
+	pathPolicy   PathPolicy
+	// redirect to the registered casing when routing case-insensitively
+	caseRedirect bool
+	// print the route table before serving
+	printRoutes bool
+
+	// routers of the hosts registered by Host
+	exactHosts   map[string]*hostRouter
+	patternHosts []*hostRouter
+
+	// registered by OnStart and OnShutdown
+	startHooks    []Hook
+	shutdownHooks []Hook
+
+	// recycle Context across requests
+	pool sync.Pool
+
+	// the listener being served, exposed by Addr
+	mu       sync.Mutex
+	listener net.Listener
+
+	// global middlewares, registered by Use
+	mws []Middleware
+	// "serve" wrapped by global middlewares
+	// rebuilt in Use, so the chain is not composed per request
+	handler HandleFunc
 }
 
-func NewHTTPServer() *HTTPServer {
-	return &HTTPServer{
-		router: newRouter(),
+func NewHTTPServer(opts ...HTTPServerOption) *HTTPServer {
+	h := &HTTPServer{
+		router:       newRadixRouter(),
+		logger:       log.Default(),
+		errorHandler: defaultErrorHandler,
+	}
+	h.server = &http.Server{Handler: h}
+	h.pool.New = func() any {
+		return &Context{
+			PathParams:   make(Params, 0, 8),
+			errorHandler: h.errorHandler,
+			tplEngine:    h.tplEngine,
+			logger:       h.logger,
+		}
+	}
+	h.handler = h.serve
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
-func (h *HTTPServer) Get(path string, handleFunc handleFunc) {
-	h.addRoute(http.MethodGet, path, handleFunc)
+// Use
+// register global middlewares, they run for every request,
+// including the ones that do not match any route
+func (h *HTTPServer) Use(mws ...Middleware) {
+	h.mws = append(h.mws, mws...)
+	h.handler = chain(h.serve, h.mws)
 }
 
-func (h *HTTPServer) Post(path string, handleFunc handleFunc) {
-	h.addRoute(http.MethodPost, path, handleFunc)
+func (h *HTTPServer) Handle(method string, path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.addRoute(method, path, handleFunc, mws...)
 }
 
-func (h *HTTPServer) Put(path string, handleFunc handleFunc) {
-	h.addRoute(http.MethodPut, path, handleFunc)
+// TryHandle
+// like Handle, but return *RouteError instead of panicking,
+// the route is not registered when an error is returned
+func (h *HTTPServer) TryHandle(method string, path string, handleFunc HandleFunc, mws ...Middleware) (*Route, error) {
+	return h.tryAddRoute(method, path, handleFunc, mws...)
 }
 
-func (h *HTTPServer) Delete(path string, handleFunc handleFunc) {
-	h.addRoute(http.MethodDelete, path, handleFunc)
+func (h *HTTPServer) Get(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodGet, path, handleFunc, mws...)
 }
 
-func (h *HTTPServer) Head(path string, handleFunc handleFunc) {
-	h.addRoute(http.MethodHead, path, handleFunc)
+func (h *HTTPServer) Post(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodPost, path, handleFunc, mws...)
 }
 
-func (h *HTTPServer) Options(path string, handleFunc handleFunc) {
-	h.addRoute(http.MethodOptions, path, handleFunc)
+func (h *HTTPServer) Put(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodPut, path, handleFunc, mws...)
 }
 
-func (h *HTTPServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	ctx := &Context{
-		Req:  request,
-		Resp: writer,
+func (h *HTTPServer) Patch(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodPatch, path, handleFunc, mws...)
+}
+
+func (h *HTTPServer) Delete(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodDelete, path, handleFunc, mws...)
+}
+
+func (h *HTTPServer) Head(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodHead, path, handleFunc, mws...)
+}
+
+func (h *HTTPServer) Options(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodOptions, path, handleFunc, mws...)
+}
+
+func (h *HTTPServer) Connect(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodConnect, path, handleFunc, mws...)
+}
+
+func (h *HTTPServer) Trace(path string, handleFunc HandleFunc, mws ...Middleware) *Route {
+	return h.Handle(http.MethodTrace, path, handleFunc, mws...)
+}
+
+// Any
+// register handleFunc on all http methods
+func (h *HTTPServer) Any(path string, handleFunc HandleFunc, mws ...Middleware) {
+	for _, method := range methods {
+		h.Handle(method, path, handleFunc, mws...)
 	}
+}
+
+func (h *HTTPServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	ctx := h.pool.Get().(*Context)
+	ctx.reset(writer, request)
+
+	h.handler(ctx)
 
-	h.Serve(ctx)
+	ctx.reset(nil, nil)
+	h.pool.Put(ctx)
 }
 
+// Start
+// listen on addr and serve until Shutdown, which makes Start return nil
+// addr is a tcp address such as ":8080",
+// or a unix domain socket path with "unix:" prefix such as "unix:/tmp/web.sock"
 func (h *HTTPServer) Start(addr string) error {
-	l, err := net.Listen("tcp", addr)
+	l, err := listen(addr)
 	if err != nil {
 		return err
 	}
-	// start hook here
+	return h.Serve(l)
+}
 
-	return http.Serve(l, h)
+// Serve
+// serve on l until Shutdown, which makes Serve return nil
+// such as a listener from systemd socket activation
+func (h *HTTPServer) Serve(l net.Listener) error {
+	return h.serveListener(l, h.server.Serve)
 }
 
-func (h *HTTPServer) Serve(ctx *Context) {
-	routeInfo, found := h.findRoute(ctx.Req.Method, ctx.Req.URL.Path)
-	if !found || routeInfo.n.handler == nil {
-		ctx.Resp.WriteHeader(404)
-		ctx.Resp.Write([]byte("NOT FOUND"))
+// Addr
+// the address being served, nil before serving
+// useful when listening on port 0
+func (h *HTTPServer) Addr() net.Addr {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.listener == nil {
+		return nil
+	}
+	return h.listener.Addr()
+}
+
+// serveListener
+// run start hooks, then serve l by serve
+func (h *HTTPServer) serveListener(l net.Listener, serve func(l net.Listener) error) error {
+	h.mu.Lock()
+	h.listener = l
+	h.mu.Unlock()
+
+	if h.printRoutes {
+		h.PrintRoutes(h.logger.Writer())
+	}
+
+	if err := h.runStartHooks(context.Background()); err != nil {
+		_ = l.Close()
+		return err
+	}
+
+	err := serve(l)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// listen
+// "unix:" prefix for unix domain socket, tcp otherwise
+func listen(addr string) (net.Listener, error) {
+	if path := strings.TrimPrefix(addr, "unix:"); path != addr {
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// serve
+// route ctx to the matched handler
+func (h *HTTPServer) serve(ctx *Context) {
+	reqPath, escaped := routingPath(ctx.Req.URL)
+	if h.pathPolicy == PathTolerant {
+		reqPath = cleanPath(reqPath)
+	}
+
+	// host labels are kept in PathParams, even if no route matches
+	r, hostParams := h.routerFor(ctx.Req.Host, ctx.PathParams)
+	ctx.PathParams = hostParams
+
+	routeInfo, found := r.findRequestRoute(ctx.Req, ctx.Req.Method, reqPath, ctx.PathParams)
+	if !found {
+		if h.pathPolicy == PathRedirect && h.redirectCanonical(ctx, r, reqPath) {
+			return
+		}
+		h.serveNotMatched(ctx, r, reqPath)
 		return
 	}
+	if h.caseRedirect && caseDiffers(routeInfo.n.route, reqPath) {
+		h.redirect(ctx, canonicalCase(routeInfo.n.route, reqPath))
+		return
+	}
+	if escaped {
+		unescapeParams(routeInfo.pathParams)
+	}
 	ctx.PathParams = routeInfo.pathParams
-	routeInfo.n.handler(ctx)
+	routeInfo.handler(ctx)
+}
+
+// redirectCanonical
+// redirect to the canonical path of reqPath if it matches a route,
+// report whether the redirect is sent
+func (h *HTTPServer) redirectCanonical(ctx *Context, r *router, reqPath string) bool {
+	canonical := cleanPath(reqPath)
+	if canonical == reqPath {
+		return false
+	}
+	if _, found := r.findRoute(ctx.Req.Method, canonical, ctx.PathParams[len(ctx.PathParams):]); !found {
+		return false
+	}
+
+	h.redirect(ctx, canonical)
+	return true
+}
+
+// redirect
+// redirect to location with the query of the request
+func (h *HTTPServer) redirect(ctx *Context, location string) {
+	if ctx.Req.URL.RawQuery != "" {
+		location += "?" + ctx.Req.URL.RawQuery
+	}
+	ctx.Resp.Header().Set("Location", location)
+	ctx.Resp.WriteHeader(redirectCode(ctx.Req.Method))
+}
+
+// serveNotMatched
+// the path may still be registered with other methods:
+// - OPTIONS, answer automatically with the allowed methods
+// - other methods, 405 with the allowed methods, by MethodNotAllowedHandler
+// - otherwise, 404 by NotFoundHandler,
+// including the routes of the method whose matchers do not match the request
+func (h *HTTPServer) serveNotMatched(ctx *Context, r *router, reqPath string) {
+	allowed := r.allowedMethods(reqPath)
+	sort.Strings(allowed)
+	i := sort.SearchStrings(allowed, ctx.Req.Method)
+	if len(allowed) == 0 || (i < len(allowed) && allowed[i] == ctx.Req.Method) {
+		if h.NotFoundHandler != nil {
+			h.NotFoundHandler(ctx)
+			return
+		}
+		ctx.Resp.WriteHeader(http.StatusNotFound)
+		ctx.Resp.Write([]byte("NOT FOUND"))
+		return
+	}
+
+	// OPTIONS is answered automatically, so it is always allowed
+	if i := sort.SearchStrings(allowed, http.MethodOptions); i == len(allowed) || allowed[i] != http.MethodOptions {
+		allowed = append(allowed, http.MethodOptions)
+		sort.Strings(allowed)
+	}
+	ctx.Resp.Header().Set("Allow", strings.Join(allowed, ", "))
+
+	if ctx.Req.Method == http.MethodOptions {
+		ctx.Resp.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if h.MethodNotAllowedHandler != nil {
+		h.MethodNotAllowedHandler(ctx)
+		return
+	}
+	ctx.Resp.WriteHeader(http.StatusMethodNotAllowed)
+	ctx.Resp.Write([]byte("METHOD NOT ALLOWED"))
 }
@@ -13,14 +13,14 @@ func TestServer(t *testing.T) {
 	// method 1: use http package
 	//http.ListenAndServe(":8080", s)
 
-	s.addRoute(http.MethodGet, "/", func(ctx *Context) {
+	s.Handle(http.MethodGet, "/", func(ctx *Context) {
 		_, err := ctx.Resp.Write([]byte("homepage"))
 		if err != nil {
 			return
 		}
 	})
 
-	s.addRoute(http.MethodPost, "/", func(ctx *Context) {
+	s.Handle(http.MethodPost, "/", func(ctx *Context) {
 		ctx.Req.ParseForm()
 		_, err := ctx.Resp.Write([]byte("homepage"))
 		if err != nil {
@@ -43,7 +43,8 @@ func TestServer(t *testing.T) {
 	})
 
 	s.Get("/order/detail/:id", func(ctx *Context) {
-		_, err := ctx.Resp.Write([]byte(fmt.Sprintf("hello, /order/detail/%s", ctx.PathParams["id"])))
+		id, _ := ctx.PathParams.Get("id")
+		_, err := ctx.Resp.Write([]byte(fmt.Sprintf("hello, /order/detail/%s", id)))
 		if err != nil {
 			return
 		}
@@ -64,7 +65,8 @@ func TestServer(t *testing.T) {
 	})
 
 	s.Get("/user/:userid(^[0-9]+$)", func(ctx *Context) {
-		_, err := ctx.Resp.Write([]byte(fmt.Sprintf("hello, your user id is %s", ctx.PathParams["userid"])))
+		userid, _ := ctx.PathParams.Get("userid")
+		_, err := ctx.Resp.Write([]byte(fmt.Sprintf("hello, your user id is %s", userid)))
 		if err != nil {
 			return
 		}
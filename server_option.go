@@ -0,0 +1,113 @@
+package web
+
+import (
+	"crypto/tls"
+	"log"
+	"time"
+)
+
+// HTTPServerOption
+// configure HTTPServer in NewHTTPServer
+// for example:
+//
+//	NewHTTPServer(ServerWithReadTimeout(time.Second), ServerWithLogger(logger))
+type HTTPServerOption func(server *HTTPServer)
+
+// ServerWithReadTimeout
+// max duration for reading the entire request, including the body
+func ServerWithReadTimeout(timeout time.Duration) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.server.ReadTimeout = timeout
+	}
+}
+
+// ServerWithWriteTimeout
+// max duration before timing out writes of the response
+func ServerWithWriteTimeout(timeout time.Duration) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.server.WriteTimeout = timeout
+	}
+}
+
+// ServerWithIdleTimeout
+// max duration to wait for the next request when keep-alives are enabled
+func ServerWithIdleTimeout(timeout time.Duration) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.server.IdleTimeout = timeout
+	}
+}
+
+// ServerWithMaxHeaderBytes
+// max bytes the server will read parsing the request header
+func ServerWithMaxHeaderBytes(n int) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.server.MaxHeaderBytes = n
+	}
+}
+
+// ServerWithTLSConfig
+// tls config of the owned http.Server, StartTLS keeps its settings except the certificates
+func ServerWithTLSConfig(cfg *tls.Config) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.server.TLSConfig = cfg
+	}
+}
+
+// ServerWithLogger
+// logger of HTTPServer, also used as the error log of the owned http.Server
+func ServerWithLogger(logger *log.Logger) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.logger = logger
+		server.server.ErrorLog = logger
+	}
+}
+
+// ServerWithNotFoundHandler
+// handler called when no route matches the request, see HTTPServer.NotFoundHandler
+func ServerWithNotFoundHandler(handleFunc HandleFunc) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.NotFoundHandler = handleFunc
+	}
+}
+
+// ServerWithErrorHandler
+// handler of the errors reported by Context.Error
+func ServerWithErrorHandler(errorHandler ErrorHandler) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.errorHandler = errorHandler
+	}
+}
+
+// ServerWithTemplateEngine
+// template engine used by Context.Render
+func ServerWithTemplateEngine(engine TemplateEngine) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.tplEngine = engine
+	}
+}
+
+// ServerWithPathPolicy
+// how the request paths which are not canonical are handled, default PathStrict
+func ServerWithPathPolicy(policy PathPolicy) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.pathPolicy = policy
+	}
+}
+
+// ServerWithCaseInsensitive
+// match static segments case-insensitively, such as "/Order/Detail" for "/order/detail"
+// redirect, redirect to the registered casing instead of serving directly
+func ServerWithCaseInsensitive(redirect bool) HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.caseInsensitive = true
+		server.caseRedirect = redirect
+	}
+}
+
+// ServerWithRouteTable
+// print the registered routes to the logger before serving
+func ServerWithRouteTable() HTTPServerOption {
+	return func(server *HTTPServer) {
+		server.printRoutes = true
+	}
+}
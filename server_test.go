@@ -0,0 +1,343 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPServer_Handle(t *testing.T) {
+	write := func(body string) HandleFunc {
+		return func(ctx *Context) {
+			_, _ = ctx.Resp.Write([]byte(body))
+		}
+	}
+
+	s := NewHTTPServer()
+	s.Handle("PROPFIND", "/dav", write("propfind"))
+	s.Patch("/user", write("patch user"))
+	s.Trace("/user", write("trace user"))
+	s.Any("/any", write("any"))
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "custom method",
+			method:     "PROPFIND",
+			path:       "/dav",
+			expectCode: http.StatusOK,
+			expectBody: "propfind",
+		},
+		{
+			name:       "patch",
+			method:     http.MethodPatch,
+			path:       "/user",
+			expectCode: http.StatusOK,
+			expectBody: "patch user",
+		},
+		{
+			name:       "trace",
+			method:     http.MethodTrace,
+			path:       "/user",
+			expectCode: http.StatusOK,
+			expectBody: "trace user",
+		},
+		{
+			name:       "any with get",
+			method:     http.MethodGet,
+			path:       "/any",
+			expectCode: http.StatusOK,
+			expectBody: "any",
+		},
+		{
+			name:       "any with delete",
+			method:     http.MethodDelete,
+			path:       "/any",
+			expectCode: http.StatusOK,
+			expectBody: "any",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestHTTPServer_MethodNotAllowed(t *testing.T) {
+	s := NewHTTPServer()
+	s.Get("/user", func(ctx *Context) {})
+	s.Post("/user", func(ctx *Context) {})
+	s.Delete("/user/:id", func(ctx *Context) {})
+	s.Options("/order", func(ctx *Context) {
+		ctx.Resp.WriteHeader(http.StatusOK)
+	})
+	s.Get("/order", func(ctx *Context) {})
+
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		expectCode  int
+		expectAllow string
+	}{
+		{
+			name:        "method not allowed",
+			method:      http.MethodPut,
+			path:        "/user",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET, OPTIONS, POST",
+		},
+		{
+			name:        "method not allowed with param",
+			method:      http.MethodGet,
+			path:        "/user/1",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "DELETE, OPTIONS",
+		},
+		{
+			name:        "automatic options",
+			method:      http.MethodOptions,
+			path:        "/user",
+			expectCode:  http.StatusNoContent,
+			expectAllow: "GET, OPTIONS, POST",
+		},
+		{
+			name:       "explicit options",
+			method:     http.MethodOptions,
+			path:       "/order",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:        "explicit options in allow",
+			method:      http.MethodPost,
+			path:        "/order",
+			expectCode:  http.StatusMethodNotAllowed,
+			expectAllow: "GET, OPTIONS",
+		},
+		{
+			name:       "not found",
+			method:     http.MethodGet,
+			path:       "/not/exist",
+			expectCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectAllow, recorder.Header().Get("Allow"))
+		})
+	}
+}
+
+func TestHTTPServer_NotMatchedHandler(t *testing.T) {
+	s := NewHTTPServer()
+	s.Get("/user", func(ctx *Context) {})
+	s.NotFoundHandler = func(ctx *Context) {
+		_ = ctx.RespJSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+	s.MethodNotAllowedHandler = func(ctx *Context) {
+		_ = ctx.RespJSON(http.StatusMethodNotAllowed, map[string]string{
+			"error": "allow: " + ctx.Resp.Header().Get("Allow"),
+		})
+	}
+	var through []string
+	s.Use(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			through = append(through, ctx.Req.URL.Path)
+			next(ctx)
+		}
+	})
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "not found",
+			method:     http.MethodGet,
+			path:       "/not/exist",
+			expectCode: http.StatusNotFound,
+			expectBody: `{"error":"not found"}`,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPost,
+			path:       "/user",
+			expectCode: http.StatusMethodNotAllowed,
+			expectBody: `{"error":"allow: GET, OPTIONS"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			through = nil
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+			assert.Equal(t, []string{tc.path}, through)
+		})
+	}
+}
+
+func TestNewHTTPServer_Options(t *testing.T) {
+	tpl, err := template.New("hello").Parse(`hello, {{.}}`)
+	assert.NoError(t, err)
+
+	s := NewHTTPServer(
+		ServerWithReadTimeout(time.Second),
+		ServerWithWriteTimeout(2*time.Second),
+		ServerWithIdleTimeout(3*time.Second),
+		ServerWithMaxHeaderBytes(1024),
+		ServerWithNotFoundHandler(func(ctx *Context) {
+			ctx.Resp.WriteHeader(http.StatusTeapot)
+		}),
+		ServerWithErrorHandler(func(ctx *Context, err error) {
+			ctx.Resp.WriteHeader(http.StatusBadGateway)
+			_, _ = ctx.Resp.Write([]byte(err.Error()))
+		}),
+		ServerWithTemplateEngine(&GoTemplateEngine{T: tpl}),
+	)
+	assert.Equal(t, time.Second, s.server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, s.server.WriteTimeout)
+	assert.Equal(t, 3*time.Second, s.server.IdleTimeout)
+	assert.Equal(t, 1024, s.server.MaxHeaderBytes)
+
+	s.Get("/error", func(ctx *Context) {
+		ctx.Error(errors.New("upstream failed"))
+	})
+	s.Get("/render", func(ctx *Context) {
+		_ = ctx.Render("hello", "Tom")
+	})
+
+	testCases := []struct {
+		name       string
+		path       string
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "not found handler",
+			path:       "/not/exist",
+			expectCode: http.StatusTeapot,
+		},
+		{
+			name:       "error handler",
+			path:       "/error",
+			expectCode: http.StatusBadGateway,
+			expectBody: "upstream failed",
+		},
+		{
+			name:       "template engine",
+			path:       "/render",
+			expectCode: http.StatusOK,
+			expectBody: "hello, Tom",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectCode, recorder.Code)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+		})
+	}
+}
+
+// discardResponseWriter
+// http.ResponseWriter without allocation, httptest.ResponseRecorder allocates
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
+
+func BenchmarkHTTPServer_ServeHTTP(b *testing.B) {
+	s := NewHTTPServer()
+	s.Get("/user/home", func(ctx *Context) {})
+	s.Get("/user/:id", func(ctx *Context) {})
+
+	benchmarks := []struct {
+		name string
+		path string
+	}{
+		{name: "static", path: "/user/home"},
+		{name: "param", path: "/user/123"},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, bm.path, nil)
+			w := &discardResponseWriter{header: http.Header{}}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+func TestHTTPServer_OptionalParam(t *testing.T) {
+	s := NewHTTPServer()
+	s.Get("/reports/:year?", func(ctx *Context) {
+		year, err := ctx.PathParamValue("year").AsInt64()
+		if err != nil {
+			_, _ = ctx.Resp.Write([]byte("all years"))
+			return
+		}
+		_, _ = ctx.Resp.Write([]byte(fmt.Sprintf("year %d", year)))
+	})
+
+	testCases := []struct {
+		path       string
+		expectBody string
+	}{
+		{path: "/reports", expectBody: "all years"},
+		{path: "/reports/2022", expectBody: "year 2022"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectBody, recorder.Body.String())
+		})
+	}
+}
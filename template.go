@@ -0,0 +1,25 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+)
+
+type TemplateEngine interface {
+	// Render
+	// render the template tplName with data, return the rendered page
+	Render(ctx context.Context, tplName string, data any) ([]byte, error)
+}
+
+// GoTemplateEngine
+// TemplateEngine based on html/template
+type GoTemplateEngine struct {
+	T *template.Template
+}
+
+func (g *GoTemplateEngine) Render(ctx context.Context, tplName string, data any) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	err := g.T.ExecuteTemplate(buffer, tplName, data)
+	return buffer.Bytes(), err
+}
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StartTLS
+// same as Start but serve HTTPS with certFile and keyFile,
+// HTTP/2 is negotiated by ALPN.
+// the files are checked at most once per certCheckInterval during TLS handshakes
+// and reloaded once they change, so certificates can be rotated without restarting
+func (h *HTTPServer) StartTLS(addr string, certFile string, keyFile string) error {
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	// keep the other settings from ServerWithTLSConfig
+	var cfg *tls.Config
+	if h.server.TLSConfig != nil {
+		cfg = h.server.TLSConfig.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+	cfg.Certificates = nil
+	cfg.GetCertificate = reloader.GetCertificate
+	return h.StartTLSConfig(addr, cfg)
+}
+
+// StartTLSConfig
+// same as Start but serve HTTPS with cfg,
+// which must provide Certificates or GetCertificate.
+// "h2" and "http/1.1" are appended to cfg.NextProtos if absent
+func (h *HTTPServer) StartTLSConfig(addr string, cfg *tls.Config) error {
+	l, err := listen(addr)
+	if err != nil {
+		return err
+	}
+
+	h.server.TLSConfig = cfg
+	return h.serveListener(l, func(l net.Listener) error {
+		// certificates are already in TLSConfig, so no files here
+		return h.server.ServeTLS(l, "", "")
+	})
+}
+
+// certCheckInterval
+// how often certReloader looks at the files
+const certCheckInterval = 10 * time.Second
+
+// certReloader
+// provide tls.Config.GetCertificate, reload the key pair when the files change
+// handshakes read the current certificate without locking,
+// only the one which is due to check the files stats them
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	cert atomic.Pointer[tls.Certificate]
+	// unix nano time of the next check
+	nextCheck atomic.Int64
+
+	// guard the mod times, held by the checking handshake only
+	mu          sync.Mutex
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: certCheckInterval,
+	}
+	r.nextCheck.Store(time.Now().Add(r.interval).UnixNano())
+	if err := r.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	// the handshake winning the swap checks the files, the others go on with the current certificate
+	now := time.Now().UnixNano()
+	if next := r.nextCheck.Load(); now >= next && r.nextCheck.CompareAndSwap(next, now+int64(r.interval)) {
+		r.mu.Lock()
+		// files may be half written during rotation,
+		// keep serving the previous certificate and retry on next check
+		_ = r.reloadIfChanged()
+		r.mu.Unlock()
+	}
+	return r.cert.Load(), nil
+}
+
+// reloadIfChanged
+// r.mu must be held, except in newCertReloader
+func (r *certReloader) reloadIfChanged() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	if r.cert.Load() != nil &&
+		certInfo.ModTime().Equal(r.certModTime) &&
+		keyInfo.ModTime().Equal(r.keyModTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert.Store(&cert)
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	return nil
+}
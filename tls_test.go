@@ -0,0 +1,145 @@
+package web
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	writeTestCert(t, certFile, keyFile, "first")
+	reloader, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "first", leafCommonName(t, reloader))
+
+	// TEST: not checked before the interval passes
+	writeTestCert(t, certFile, keyFile, "second")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	assert.Equal(t, "first", leafCommonName(t, reloader))
+
+	// TEST: rotate, check on every handshake from now on
+	reloader.interval = 0
+	reloader.nextCheck.Store(0)
+	assert.Equal(t, "second", leafCommonName(t, reloader))
+
+	// TEST: broken files keep the previous certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	assert.Equal(t, "second", leafCommonName(t, reloader))
+
+	// TEST: concurrent handshakes, run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				cert, err := reloader.GetCertificate(nil)
+				assert.NoError(t, err)
+				assert.NotNil(t, cert)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// TEST: invalid files at start
+	_, err = newCertReloader(certFile, keyFile)
+	assert.Error(t, err)
+}
+
+func leafCommonName(t *testing.T, reloader *certReloader) string {
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+// writeTestCert
+// write a self-signed certificate for localhost
+func writeTestCert(t *testing.T, certFile string, keyFile string, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+}
+
+func TestHTTPServer_StartTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, "localhost")
+
+	s := NewHTTPServer()
+	s.Get("/proto", func(ctx *Context) {
+		_, _ = ctx.Resp.Write([]byte(ctx.Req.Proto))
+	})
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- s.StartTLS("127.0.0.1:0", certFile, keyFile)
+	}()
+	require.Eventually(t, func() bool {
+		return s.Addr() != nil
+	}, time.Second, time.Millisecond)
+
+	certPEM, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool, ServerName: "localhost"},
+			ForceAttemptHTTP2: true,
+		},
+	}
+
+	resp, err := client.Get("https://" + s.Addr().String() + "/proto")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "HTTP/2.0", string(body))
+
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assert.NoError(t, <-startErr)
+}